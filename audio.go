@@ -139,3 +139,13 @@ func GetAudioProperties() []string {
 		PropertyKeyEnriched,
 	}
 }
+
+func init() {
+	mustRegisterType(TypeDefinition{
+		DataType:     TypeAudio,
+		RenderFunc:   renderWith(RenderAudioProperties),
+		PropertyKeys: GetAudioProperties(),
+		Content:      BlockContentTypeStructural,
+		Final:        true,
+	})
+}
@@ -6,14 +6,24 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// LastError describes the last failure of the block while it was processed.
+// Failures are retryable unless marked NonRetryable, so records that only have a Message are retried.
 type LastError struct {
-	Message string `json:"message"`
+	Message       string       `json:"message"`
+	Stage         FailureStage `json:"stage,omitempty"`
+	Code          string       `json:"code,omitempty"`
+	NonRetryable  bool         `json:"non_retryable,omitempty"`
+	Attempts      int          `json:"attempts,omitempty"`
+	FirstFailedAt *time.Time   `json:"first_failed_at,omitempty"`
+	LastFailedAt  *time.Time   `json:"last_failed_at,omitempty"`
+	NextRetryAt   *time.Time   `json:"next_retry_at,omitempty"`
 }
 
 type Block struct {
@@ -32,6 +42,7 @@ type Block struct {
 	ChildrenRecursive []uuid.UUID     `json:"children_recursive"`
 	RawBody           string          `json:"raw_body"` // html, email, etc
 	LifecycleStatus   LifecycleStatus `json:"lifecycle_status"`
+	StatusHistory     []StatusChange  `json:"status_history,omitempty"`
 	Metadata          json.RawMessage `json:"metadata,omitempty"`
 	Origin            Origin          `json:"origin"`
 	MovesHistory      []Move          `json:"moves_history"`
@@ -40,6 +51,11 @@ type Block struct {
 	CreatedAt         time.Time       `json:"created_at"`
 	UpdatedAt         time.Time       `json:"updated_at"`
 
+	// Version identifies the state of the block for optimistic concurrency. It is increased by every accepted
+	// write through CompareAndSwap or UpdateBlock, including writes of blocks merged with MergeBlocks, and when
+	// a revision is recorded. Writers compare it, or the ETag derived from it, to detect concurrent modifications.
+	Version int64 `json:"version"`
+
 	// Meaning contains a short explanation of what this block is about.
 	// This field should be set only for root blocks (blocks without parents).
 	Meaning string `json:"meaning,omitempty"`
@@ -111,13 +127,13 @@ func (b *Block) AnnotationID() string {
 	return fmt.Sprintf("[[block:%s]]", b.ID.String())
 }
 
+// referencePattern matches the [[block:uuid]] annotation
+var referencePattern = regexp.MustCompile(`\[\[block:([0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12})\]\]`)
+
 // ExtractReferences extracts all UUIDs from a string that match the [[block:uuid]] pattern
 func ExtractReferences(text string) []uuid.UUID {
-	// Regex to match [[block:uuid]] pattern
-	pattern := regexp.MustCompile(`\[\[block:([0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12})\]\]`)
-
 	// Find all matches
-	matches := pattern.FindAllStringSubmatch(text, -1)
+	matches := referencePattern.FindAllStringSubmatch(text, -1)
 
 	// Extract UUIDs from matches
 	uuids := make([]uuid.UUID, 0, len(matches))
@@ -134,6 +150,8 @@ func ExtractReferences(text string) []uuid.UUID {
 	return uuids
 }
 
+// Keywords returns the distinct space and reasoning keywords of the moves in alphabetical order, as recorded.
+// Use RankedKeywords for normalized keywords ordered by relevance.
 func (b *Block) Keywords() []string {
 	uniqueKeywords := make(map[string]bool)
 	for _, move := range b.MovesHistory {
@@ -149,6 +167,7 @@ func (b *Block) Keywords() []string {
 	for keyword := range uniqueKeywords {
 		keywords = append(keywords, keyword)
 	}
+	sort.Strings(keywords)
 	return keywords
 }
 
@@ -208,19 +227,29 @@ func (b *Block) GetFirstNContent(n int) []uuid.UUID {
 	return b.Content
 }
 
+// DefaultMovesHistoryLimit is the number of most recent moves kept by AddMove
+const DefaultMovesHistoryLimit = 20
+
 // AddMove adds a move record to the block's move history
-// It maintains a maximum of 20 most recent moves
+// It maintains a maximum of DefaultMovesHistoryLimit most recent moves
 func (b *Block) AddMove(move Move) {
-	// Keep only last 20 moves
-	if len(b.MovesHistory) >= 20 {
-		// Remove first element by slicing from index 1 onwards
-		b.MovesHistory = b.MovesHistory[1:]
-	}
+	b.AddMoveWithLimit(move, DefaultMovesHistoryLimit)
+}
+
+// AddMoveWithLimit adds a move record to the block's move history, keeping only the limit most recent moves.
+// A limit that is not positive keeps the whole history.
+func (b *Block) AddMoveWithLimit(move Move, limit int) {
 	b.MovesHistory = append(b.MovesHistory, move)
+	if limit > 0 && len(b.MovesHistory) > limit {
+		// Drop the oldest moves
+		b.MovesHistory = b.MovesHistory[len(b.MovesHistory)-limit:]
+	}
 }
 
-// UpdateFromJSON updates the Block from JSON data and returns a list of updated field names.
-// It only updates fields that exist in the JSON and differ from the current Block.
+// UpdateFromJSON updates the Block from a JSON merge patch (RFC 7396) and returns a list of updated field names.
+// Fields missing from the patch are left untouched and an explicit null resets a field to its zero value.
+// Objects are merged member by member: properties and styles key by key, where a null key removes the property.
+// Fields whose value cannot be decoded are skipped.
 func (b *Block) UpdateFromJSON(data []byte) ([]string, error) {
 	// First unmarshal into a map to get the raw JSON properties
 	var rawData map[string]json.RawMessage
@@ -231,77 +260,29 @@ func (b *Block) UpdateFromJSON(data []byte) ([]string, error) {
 	// Track which fields were updated
 	updatedFields := []string{}
 
-	// Create a map of JSON field names to struct fields
-	jsonToStructField := make(map[string]string)
-
-	// Use reflection to build the map and handle the update
 	v := reflect.ValueOf(b).Elem()
 	t := v.Type()
 
+	// Walk the struct fields so updated fields are reported in a stable order
 	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		jsonFieldName := getJSONFieldName(field)
-		jsonToStructField[jsonFieldName] = field.Name
-	}
-
-	// Create a temporary Block to store the current state for comparison
-	currentBlock := *b
+		jsonField := getJSONFieldName(t.Field(i))
 
-	// Iterate through the raw JSON data
-	for jsonField, rawValue := range rawData {
 		// Skip UpdatedAt as we'll set it at the end
-		if jsonField == "updated_at" {
+		if jsonField == BlockPropertyUpdatedAt {
 			continue
 		}
 
-		// Look up the corresponding struct field
-		structFieldName, exists := jsonToStructField[jsonField]
+		rawValue, exists := rawData[jsonField]
 		if !exists {
-			// JSON field doesn't correspond to any struct field, skip it
-			continue
-		}
-
-		// Get the field by name
-		fieldIndex := -1
-		for i := 0; i < t.NumField(); i++ {
-			if t.Field(i).Name == structFieldName {
-				fieldIndex = i
-				break
-			}
-		}
-
-		if fieldIndex == -1 {
-			// Field not found in struct, skip it
 			continue
 		}
 
-		// Get the field and its type
-		field := v.Field(fieldIndex)
-		fieldType := field.Type()
-
-		// Create a new instance of the field's type to unmarshal into
-		newValue := reflect.New(fieldType).Interface()
-
-		// Unmarshal the raw JSON value into the new instance
-		if err := json.Unmarshal(rawValue, newValue); err != nil {
+		changed, err := mergePatchField(v.Field(i), rawValue)
+		if err != nil {
 			// Skip fields that can't be unmarshaled
 			continue
 		}
-
-		// Extract the value from the pointer
-		newValueElem := reflect.ValueOf(newValue).Elem()
-
-		// Skip if the field has zero value
-		if reflect.DeepEqual(newValueElem.Interface(), reflect.Zero(fieldType).Interface()) {
-			continue
-		}
-
-		// Get current value for comparison
-		currentField := reflect.ValueOf(currentBlock).FieldByName(structFieldName)
-
-		// Only update and track fields that have changed
-		if !reflect.DeepEqual(currentField.Interface(), newValueElem.Interface()) {
-			field.Set(newValueElem)
+		if changed {
 			updatedFields = append(updatedFields, jsonField)
 		}
 	}
@@ -309,19 +290,72 @@ func (b *Block) UpdateFromJSON(data []byte) ([]string, error) {
 	// Update the UpdatedAt field
 	if len(updatedFields) > 0 {
 		b.UpdatedAt = time.Now()
-		updatedFields = append(updatedFields, "updated_at")
+		updatedFields = append(updatedFields, BlockPropertyUpdatedAt)
 	}
 
 	return updatedFields, nil
 }
 
+// Copy returns a deep copy of the block with the same identity.
+// Properties, slices and pointers are not shared with the original.
+func (b *Block) Copy() Block {
+	c := *b
+
+	c.RootParentID = copyIDPointer(b.RootParentID)
+	c.ParentID = copyIDPointer(b.ParentID)
+	c.Properties = b.Properties.Copy()
+	c.Styles = b.Styles.Copy()
+	c.Origin.ModifiedBy = copyStringPointer(b.Origin.ModifiedBy)
+
+	if b.Content != nil {
+		c.Content = append([]uuid.UUID{}, b.Content...)
+	}
+	if b.ChildrenRecursive != nil {
+		c.ChildrenRecursive = append([]uuid.UUID{}, b.ChildrenRecursive...)
+	}
+	if b.Metadata != nil {
+		c.Metadata = append(json.RawMessage{}, b.Metadata...)
+	}
+	if b.StatusHistory != nil {
+		c.StatusHistory = append([]StatusChange{}, b.StatusHistory...)
+	}
+	if b.MovesHistory != nil {
+		c.MovesHistory = make([]Move, len(b.MovesHistory))
+		for i, move := range b.MovesHistory {
+			if move.ReasoningKeywords != nil {
+				move.ReasoningKeywords = append([]string{}, move.ReasoningKeywords...)
+			}
+			if move.SpaceKeywords != nil {
+				move.SpaceKeywords = append([]string{}, move.SpaceKeywords...)
+			}
+			move.FromSpaceID = copyIDPointer(move.FromSpaceID)
+			move.ToSpaceID = copyIDPointer(move.ToSpaceID)
+			if move.FromIndex != nil {
+				index := *move.FromIndex
+				move.FromIndex = &index
+			}
+			c.MovesHistory[i] = move
+		}
+	}
+	c.LastViewedAt = copyTimePointer(b.LastViewedAt)
+	c.LastError.FirstFailedAt = copyTimePointer(b.LastError.FirstFailedAt)
+	c.LastError.LastFailedAt = copyTimePointer(b.LastError.LastFailedAt)
+	c.LastError.NextRetryAt = copyTimePointer(b.LastError.NextRetryAt)
+	c.Classification = b.Classification.Copy()
+	if b.DenseVector != nil {
+		c.DenseVector = append([]float32{}, b.DenseVector...)
+	}
+
+	return c
+}
+
 // Clone returns a copy of the block with a new ID and updated timestamps.
 // The cloned block will have the same content and properties but a fresh identity.
 func (b *Block) Clone() Block {
 	now := time.Now()
 
 	// Create a deep copy of the block
-	clone := *b
+	clone := b.Copy()
 
 	// Generate new ID
 	clone.ID = uuid.New()
@@ -329,6 +363,7 @@ func (b *Block) Clone() Block {
 	// Update timestamps
 	clone.CreatedAt = now
 	clone.UpdatedAt = now
+	clone.Version = 0
 
 	return clone
 }
@@ -376,6 +411,22 @@ func (b *Block) CreateChild() Block {
 	return child
 }
 
+func copyTimePointer(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	copied := *t
+	return &copied
+}
+
+func copyStringPointer(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	copied := *s
+	return &copied
+}
+
 func NewEmptyBlock() Block {
 	return Block{
 		ID:         uuid.New(),
@@ -406,58 +457,11 @@ func getJSONFieldName(field reflect.StructField) string {
 	return field.Name
 }
 
+// RenderProperties renders the block properties using the TypeHandler registered for the block type
 func RenderProperties(ctx context.Context, b Block) string {
-	switch b.Type {
-	case TypeParagraph:
-		return RenderParagraphProperties(b)
-	case TypePage:
-		// For page and text blocks, also use the title property if available
-		title, ok := b.Properties.Get(PropertyKeyTitle)
-		if !ok {
-			return ""
-		}
-		titleString, ok := title.(string)
-		if !ok {
-			return ""
-		}
-		return titleString
-	case TypeLink:
-		return RenderLinkProperties(b)
-	case TypeToDo:
-		return RenderToDoProperties(b)
-	case TypeYouTube:
-		return RenderYoutubeProperties(b)
-	case TypeMovie:
-		return RenderMovieProperties(b)
-	case TypeSeries:
-		return RenderSeriesProperties(b)
-	case TypeEmail:
-		return RenderEmailProperties(b)
-	case TypeInstagram:
-		return RenderInstagramProperties(b)
-	case TypeBook:
-		return RenderBookProperties(b)
-	case TypeLine:
-		return RenderLineProperties(b)
-	case TypePerson:
-		return RenderPersonProperties(b)
-	case TypePlace:
-		return RenderPlaceProperties(b)
-	case TypeImage:
-		return RenderImageProperties(b)
-	case TypeAudio:
-		return RenderAudioProperties(b)
-	case TypeVideo:
-		return RenderVideoProperties(b)
-	case TypeFile:
-		return RenderFileProperties(b)
-	case TypeHeader1, TypeHeader2, TypeHeader3, TypeHeader4, TypeHeader5, TypeHeader6:
-		return RenderHeaderProperties(b)
-	case TypeNumberedListItem:
-		return RenderNumberedListItemProperties(b)
-	case TypeBulletListItem:
-		return RenderBulletListItemProperties(b)
-	default:
+	h, ok := LookupType(b.Type)
+	if !ok {
 		return ""
 	}
+	return h.Render(ctx, b)
 }
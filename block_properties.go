@@ -1,6 +1,7 @@
 package blocks
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"strconv"
@@ -26,6 +27,7 @@ const (
 	BlockPropertyChildrenRecursive = "children_recursive"
 	BlockPropertyRawBody           = "raw_body"
 	BlockPropertyLifecycleStatus   = "lifecycle_status"
+	BlockPropertyStatusHistory     = "status_history"
 	BlockPropertyMetadata          = "metadata"
 	BlockPropertyOrigin            = "origin"
 	BlockPropertyMovesHistory      = "moves_history"
@@ -33,6 +35,7 @@ const (
 	BlockPropertyLastViewedAt      = "last_viewed_at"
 	BlockPropertyCreatedAt         = "created_at"
 	BlockPropertyUpdatedAt         = "updated_at"
+	BlockPropertyVersion           = "version"
 )
 
 // PropertyType defines the expected type for a property value
@@ -295,10 +298,12 @@ func (p Properties) MarshalJSON() ([]byte, error) {
 }
 
 // UnmarshalJSON implements the json.Unmarshaler interface for Properties.
-// It parses a JSON object into a Properties map.
+// It parses a JSON object into a Properties map and normalizes every value through
+// the declared PropertyType, so integers come back as int, date/times as time.Time and
+// nested string arrays as []string. Keys without a declared type are preserved as decoded.
 func (p *Properties) UnmarshalJSON(data []byte) error {
 	// Create a temporary map to unmarshal into
-	var temp map[string][]interface{}
+	var temp map[string]json.RawMessage
 
 	if err := json.Unmarshal(data, &temp); err != nil {
 		return err
@@ -309,14 +314,118 @@ func (p *Properties) UnmarshalJSON(data []byte) error {
 		*p = Properties{}
 	}
 
-	// Copy values from temp map to Properties
-	for k, v := range temp {
-		(*p)[k] = v
+	// Decode and copy values from temp map to Properties
+	for k, raw := range temp {
+		values, err := decodePropertyValues(k, raw)
+		if err != nil {
+			return fmt.Errorf("failed to decode property %s: %w", k, err)
+		}
+		(*p)[k] = values
 	}
 
 	return nil
 }
 
+// decodePropertyValues decodes the JSON array of a single property and normalizes its values
+func decodePropertyValues(key string, raw json.RawMessage) ([]interface{}, error) {
+	var values []interface{}
+
+	propType := getPropertyType(key)
+	if propType == TypeAny {
+		// Unknown keys are kept exactly as the standard decoder returns them
+		if err := json.Unmarshal(raw, &values); err != nil {
+			return nil, err
+		}
+		return values, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&values); err != nil {
+		return nil, err
+	}
+
+	for i, value := range values {
+		values[i] = normalizeValue(propType, value)
+	}
+
+	return values, nil
+}
+
+// normalizeValue converts a decoded JSON value to the Go type used for the given property type.
+// Values that cannot be converted are returned as decoded, with numbers as float64.
+func normalizeValue(propType PropertyType, value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		switch propType {
+		case TypeInt:
+			if i, err := v.Int64(); err == nil {
+				return int(i)
+			}
+			if f, err := v.Float64(); err == nil && f == float64(int64(f)) {
+				return int(f)
+			}
+		case TypeString:
+			return v.String()
+		}
+		f, _ := v.Float64()
+		return f
+
+	case string:
+		if propType == TypeDateTime {
+			if t, ok := parseTimeString(v); ok {
+				return t
+			}
+		}
+		return v
+
+	case []interface{}:
+		switch propType {
+		case TypeStringArray:
+			strs := make([]string, 0, len(v))
+			for _, item := range v {
+				str, ok := item.(string)
+				if !ok {
+					break
+				}
+				strs = append(strs, str)
+			}
+			if len(strs) == len(v) {
+				return strs
+			}
+		case TypeFloatArray:
+			floats := make([]float64, 0, len(v))
+			for _, item := range v {
+				num, ok := item.(json.Number)
+				if !ok {
+					break
+				}
+				f, err := num.Float64()
+				if err != nil {
+					break
+				}
+				floats = append(floats, f)
+			}
+			if len(floats) == len(v) {
+				return floats
+			}
+		}
+		for i, item := range v {
+			v[i] = normalizeValue(TypeAny, item)
+		}
+		return v
+
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = normalizeValue(TypeAny, item)
+		}
+		return v
+
+	default:
+		return v
+	}
+}
+
 // Has checks if a property exists and has at least one value
 func (p Properties) Has(key string) bool {
 	values, exists := p[key]
@@ -406,17 +515,25 @@ func (p Properties) GetTime(key string) (time.Time, bool) {
 		case time.Time:
 			return v, true
 		case string:
-			// Try to parse the time string - add more layouts as needed
-			layouts := []string{
-				time.RFC3339,
-				"2006-01-02",
-				"2006-01-02 15:04:05",
-			}
-			for _, layout := range layouts {
-				if t, err := time.Parse(layout, v); err == nil {
-					return t, true
-				}
-			}
+			return parseTimeString(v)
+		}
+	}
+	return time.Time{}, false
+}
+
+// timeLayouts lists the layouts accepted for date/time strings - add more layouts as needed
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+}
+
+// parseTimeString parses a date/time string using any of the accepted layouts
+func parseTimeString(s string) (time.Time, bool) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
 		}
 	}
 	return time.Time{}, false
@@ -517,16 +634,8 @@ func convertValue(key string, value interface{}) (interface{}, error) {
 		case time.Time:
 			return v, nil
 		case string:
-			// Try to parse the time string - add more layouts as needed
-			layouts := []string{
-				time.RFC3339,
-				"2006-01-02",
-				"2006-01-02 15:04:05",
-			}
-			for _, layout := range layouts {
-				if t, err := time.Parse(layout, v); err == nil {
-					return t, nil
-				}
+			if t, ok := parseTimeString(v); ok {
+				return t, nil
 			}
 			return nil, fmt.Errorf("cannot parse %v as time", v)
 		default:
@@ -603,3 +712,54 @@ func (p Properties) Delete(key string) bool {
 	}
 	return exists
 }
+
+// Copy returns a deep copy of the properties, including nested arrays and maps
+func (p Properties) Copy() Properties {
+	if p == nil {
+		return nil
+	}
+
+	copied := make(Properties, len(p))
+	for key, values := range p {
+		copied[key] = copyPropertyValues(values)
+	}
+	return copied
+}
+
+// copyPropertyValues returns a deep copy of the values of a single property
+func copyPropertyValues(values []interface{}) []interface{} {
+	if values == nil {
+		return nil
+	}
+	copied := make([]interface{}, len(values))
+	for i, value := range values {
+		copied[i] = copyPropertyValue(value)
+	}
+	return copied
+}
+
+// copyPropertyValue copies the slices and maps a property value can hold
+func copyPropertyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = copyPropertyValue(item)
+		}
+		return copied
+	case []string:
+		return append([]string{}, v...)
+	case []float64:
+		return append([]float64{}, v...)
+	case []int:
+		return append([]int{}, v...)
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			copied[key] = copyPropertyValue(item)
+		}
+		return copied
+	default:
+		return value
+	}
+}
@@ -107,7 +107,7 @@ func TestProperties_UnmarshalJSON(t *testing.T) {
 			jsonString: `{"title":["The Matrix"],"release_year":[1999],"rating":[8.7],"in_production":[false]}`,
 			want: Properties{
 				PropertyKeyTitle:        {"The Matrix"},
-				PropertyKeyReleaseYear:  {float64(1999)}, // JSON numbers are parsed as float64 by default
+				PropertyKeyReleaseYear:  {1999}, // JSON numbers are normalized through the declared property type
 				PropertyKeyRating:       {float64(8.7)},
 				PropertyKeyInProduction: {false},
 			},
@@ -185,6 +185,33 @@ func TestProperties_RoundTrip(t *testing.T) {
 	}
 }
 
+func TestProperties_TypeFaithfulRoundTrip(t *testing.T) {
+	dueDate := time.Date(2023, 5, 15, 14, 30, 0, 0, time.UTC)
+	offset := 30 * time.Minute
+	title := "Buy groceries"
+
+	original := Properties{}
+	block := Block{Type: TypeToDo, Properties: original}
+	assert.NoError(t, AddToDoProperties(&block, &title, nil, &dueDate, &offset))
+	original[PropertyKeyReleaseYear] = []interface{}{2010}
+	original[PropertyKeyGenres] = []interface{}{[]string{"Action", "Sci-Fi"}}
+	original[PropertyKeyCoordinates] = []interface{}{[]float64{52.52, 13.405}}
+	original["custom_key"] = []interface{}{"kept", float64(3)}
+
+	jsonData, err := json.Marshal(original)
+	assert.NoError(t, err)
+
+	var result Properties
+	assert.NoError(t, json.Unmarshal(jsonData, &result))
+
+	assert.Equal(t, original, result)
+
+	// A block loaded from JSON must render exactly like the one that was saved
+	loaded := Block{Type: TypeToDo, Properties: result}
+	assert.Equal(t, RenderToDoProperties(block), RenderToDoProperties(loaded))
+	assert.Contains(t, RenderToDoProperties(loaded), "Due: 2023-05-15 14:30")
+}
+
 func TestUpdateFromJSON(t *testing.T) {
 	t.Run("update multiple fields", func(t *testing.T) {
 		// Create a block with initial values
@@ -353,8 +380,7 @@ func TestUpdateFromJSON(t *testing.T) {
 			LastViewedAt: &viewedAt,
 		}
 
-		// Note: In typical JSON unmarshaling, omitted fields (not null fields) are not
-		// modified. Our function should skip any zero-value fields to emulate this behavior.
+		// Note: As in a JSON merge patch, omitted fields (not null fields) are not modified.
 		// This test verifies the fields aren't changed when the fields aren't present in JSON.
 		updatedFields, err := block.UpdateFromJSON([]byte(`{
 			"raw_body": "New content"
@@ -368,6 +394,94 @@ func TestUpdateFromJSON(t *testing.T) {
 	})
 }
 
+func TestUpdateFromJSON_MergePatch(t *testing.T) {
+	t.Run("null and zero values clear fields", func(t *testing.T) {
+		parentID := uuid.New()
+		viewedAt := time.Now()
+
+		block := &Block{
+			ID:             uuid.New(),
+			Type:           TypeFragment,
+			ParentID:       &parentID,
+			Meaning:        "A recipe",
+			Content:        []uuid.UUID{uuid.New()},
+			LastViewedAt:   &viewedAt,
+			Classification: Classification{"recipe": 0.9},
+			Metadata:       json.RawMessage(`{"source":"web"}`),
+		}
+
+		updatedFields, err := block.UpdateFromJSON([]byte(`{
+			"parent_id": null,
+			"meaning": "",
+			"content": [],
+			"last_viewed_at": null,
+			"classification": null,
+			"metadata": null
+		}`))
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"parent_id", "content", "metadata", "last_viewed_at", "meaning", "classification", "updated_at"}, updatedFields)
+		assert.Nil(t, block.ParentID)
+		assert.Empty(t, block.Meaning)
+		assert.Equal(t, []uuid.UUID{}, block.Content)
+		assert.Nil(t, block.LastViewedAt)
+		assert.Empty(t, block.Classification)
+		assert.Nil(t, block.Metadata)
+	})
+
+	t.Run("properties are merged key by key", func(t *testing.T) {
+		block := &Block{
+			ID:   uuid.New(),
+			Type: TypeMovie,
+			Properties: Properties{
+				PropertyKeyTitle:       {"The Matrix"},
+				PropertyKeyReleaseYear: {1999},
+				PropertyKeyRating:      {"8.7"},
+			},
+		}
+
+		updatedFields, err := block.UpdateFromJSON([]byte(`{
+			"properties": {"title": ["The Matrix Reloaded"], "rating": null, "release_year": [1999]}
+		}`))
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"properties", "updated_at"}, updatedFields)
+		assert.Equal(t, Properties{
+			PropertyKeyTitle:       {"The Matrix Reloaded"},
+			PropertyKeyReleaseYear: {1999},
+		}, block.Properties)
+
+		updatedFields, err = block.UpdateFromJSON([]byte(`{"properties": {"release_year": [1999]}}`))
+		assert.NoError(t, err)
+		assert.Empty(t, updatedFields)
+	})
+
+	t.Run("objects are merged recursively", func(t *testing.T) {
+		block := &Block{
+			ID:             uuid.New(),
+			Type:           TypeFragment,
+			Origin:         NewOriginWebapp(),
+			Classification: Classification{"recipe": 0.9, "note": 0.1},
+			Metadata:       json.RawMessage(`{"source":"web","tags":{"a":1,"b":2}}`),
+		}
+		identifier := block.Origin.ConnectorUniqSourceIdentifier
+
+		updatedFields, err := block.UpdateFromJSON([]byte(`{
+			"origin": {"modified_by": "editor"},
+			"classification": {"note": null, "movie": 0.4},
+			"metadata": {"tags": {"a": null}}
+		}`))
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"metadata", "origin", "classification", "updated_at"}, updatedFields)
+		assert.Equal(t, "webapp", block.Origin.ConnectorSlug)
+		assert.Equal(t, identifier, block.Origin.ConnectorUniqSourceIdentifier)
+		assert.Equal(t, "editor", *block.Origin.ModifiedBy)
+		assert.Equal(t, Classification{"recipe": 0.9, "movie": 0.4}, block.Classification)
+		assert.JSONEq(t, `{"source":"web","tags":{"b":2}}`, string(block.Metadata))
+	})
+}
+
 func TestGetJSONFieldName(t *testing.T) {
 	type TestStruct struct {
 		RegularField   string `json:"regular_field"`
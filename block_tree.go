@@ -0,0 +1,428 @@
+package blocks
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// BlockTree is an in-memory index over a set of blocks.
+// Children are indexed from the Content of every block, which defines their order,
+// and parents are indexed from ParentID. Blocks without a parent in the tree are roots.
+type BlockTree struct {
+	blocks   map[uuid.UUID]Block
+	order    []uuid.UUID
+	parents  map[uuid.UUID]uuid.UUID
+	children map[uuid.UUID][]uuid.UUID
+	roots    []uuid.UUID
+
+	// position of every block in order, used to keep roots in order
+	position map[uuid.UUID]int
+	// listedIn and claimedBy index, for every ID including missing ones, the blocks
+	// that have it in their Content and the blocks that have it as ParentID
+	listedIn  map[uuid.UUID]map[uuid.UUID]bool
+	claimedBy map[uuid.UUID]map[uuid.UUID]bool
+}
+
+// NewBlockTree builds a tree from the given blocks.
+// It returns an error if the same ID appears more than once.
+func NewBlockTree(blocks []Block) (*BlockTree, error) {
+	tree := &BlockTree{
+		blocks: make(map[uuid.UUID]Block, len(blocks)),
+		order:  make([]uuid.UUID, 0, len(blocks)),
+	}
+
+	for _, b := range blocks {
+		if _, exists := tree.blocks[b.ID]; exists {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateBlockID, b.ID)
+		}
+		tree.blocks[b.ID] = b
+		tree.order = append(tree.order, b.ID)
+	}
+
+	tree.reindex()
+	return tree, nil
+}
+
+// reindex rebuilds all indexes
+func (t *BlockTree) reindex() {
+	t.parents = make(map[uuid.UUID]uuid.UUID, len(t.blocks))
+	t.children = make(map[uuid.UUID][]uuid.UUID, len(t.blocks))
+	t.roots = make([]uuid.UUID, 0)
+	t.position = make(map[uuid.UUID]int, len(t.blocks))
+	t.listedIn = make(map[uuid.UUID]map[uuid.UUID]bool, len(t.blocks))
+	t.claimedBy = make(map[uuid.UUID]map[uuid.UUID]bool)
+
+	for i, id := range t.order {
+		t.position[id] = i
+	}
+	for _, id := range t.order {
+		t.link(t.blocks[id])
+	}
+}
+
+// link adds the index entries contributed by the block itself, which must already be stored in the tree
+func (t *BlockTree) link(b Block) {
+	for _, childID := range b.Content {
+		addIndexEntry(t.listedIn, childID, b.ID)
+	}
+	if children := t.existingIDs(b.Content); len(children) > 0 {
+		t.children[b.ID] = children
+	}
+
+	if b.ParentID != nil {
+		addIndexEntry(t.claimedBy, *b.ParentID, b.ID)
+		if _, exists := t.blocks[*b.ParentID]; exists {
+			t.parents[b.ID] = *b.ParentID
+			return
+		}
+	}
+	t.addRoot(b.ID)
+}
+
+// unlink removes the index entries contributed by the block itself
+func (t *BlockTree) unlink(b Block) {
+	for _, childID := range b.Content {
+		deleteIndexEntry(t.listedIn, childID, b.ID)
+	}
+	delete(t.children, b.ID)
+
+	if b.ParentID != nil {
+		deleteIndexEntry(t.claimedBy, *b.ParentID, b.ID)
+	}
+	if _, exists := t.parents[b.ID]; exists {
+		delete(t.parents, b.ID)
+	} else {
+		t.removeRoot(b.ID)
+	}
+}
+
+// existingIDs returns the IDs that are in the tree, keeping their order
+func (t *BlockTree) existingIDs(ids []uuid.UUID) []uuid.UUID {
+	var existing []uuid.UUID
+	for _, id := range ids {
+		if _, exists := t.blocks[id]; exists {
+			existing = append(existing, id)
+		}
+	}
+	return existing
+}
+
+// addRoot inserts the block into roots at its position in the tree order
+func (t *BlockTree) addRoot(id uuid.UUID) {
+	i := t.rootIndex(id)
+	if i < len(t.roots) && t.roots[i] == id {
+		return
+	}
+	t.roots = append(t.roots, uuid.Nil)
+	copy(t.roots[i+1:], t.roots[i:])
+	t.roots[i] = id
+}
+
+// removeRoot removes the block from roots, if it is one
+func (t *BlockTree) removeRoot(id uuid.UUID) {
+	i := t.rootIndex(id)
+	if i < len(t.roots) && t.roots[i] == id {
+		t.roots = append(t.roots[:i], t.roots[i+1:]...)
+	}
+}
+
+// rootIndex returns the index of the block in roots, or where it would be inserted
+func (t *BlockTree) rootIndex(id uuid.UUID) int {
+	position := t.position[id]
+	return sort.Search(len(t.roots), func(i int) bool {
+		return t.position[t.roots[i]] >= position
+	})
+}
+
+func addIndexEntry(index map[uuid.UUID]map[uuid.UUID]bool, key, id uuid.UUID) {
+	if index[key] == nil {
+		index[key] = make(map[uuid.UUID]bool)
+	}
+	index[key][id] = true
+}
+
+func deleteIndexEntry(index map[uuid.UUID]map[uuid.UUID]bool, key, id uuid.UUID) {
+	delete(index[key], id)
+	if len(index[key]) == 0 {
+		delete(index, key)
+	}
+}
+
+// Len returns the number of blocks in the tree
+func (t *BlockTree) Len() int {
+	return len(t.order)
+}
+
+// Has reports whether the tree contains a block with the given ID
+func (t *BlockTree) Has(id uuid.UUID) bool {
+	_, exists := t.blocks[id]
+	return exists
+}
+
+// Get returns the block with the given ID
+func (t *BlockTree) Get(id uuid.UUID) (Block, bool) {
+	b, exists := t.blocks[id]
+	return b, exists
+}
+
+// Blocks returns all blocks in the order they were added
+func (t *BlockTree) Blocks() []Block {
+	return t.collect(t.order)
+}
+
+// Lookup returns a map of all blocks, suitable for RenderContent and the other renderers
+func (t *BlockTree) Lookup() map[uuid.UUID]Block {
+	lookup := make(map[uuid.UUID]Block, len(t.blocks))
+	for id, b := range t.blocks {
+		lookup[id] = b
+	}
+	return lookup
+}
+
+// Put adds a block to the tree or replaces the block with the same ID.
+// Only the index entries of the block and of the blocks that refer to it are updated.
+func (t *BlockTree) Put(b Block) {
+	old, exists := t.blocks[b.ID]
+	if exists {
+		t.unlink(old)
+	} else {
+		t.position[b.ID] = len(t.order)
+		t.order = append(t.order, b.ID)
+	}
+	t.blocks[b.ID] = b
+	t.link(b)
+
+	if exists {
+		return
+	}
+	// A new block can complete the Content of blocks listing it and adopt blocks that were waiting for it as parent
+	for parentID := range t.listedIn[b.ID] {
+		if parentID != b.ID {
+			t.children[parentID] = t.existingIDs(t.blocks[parentID].Content)
+		}
+	}
+	for childID := range t.claimedBy[b.ID] {
+		if _, hasParent := t.parents[childID]; !hasParent {
+			t.removeRoot(childID)
+			t.parents[childID] = b.ID
+		}
+	}
+}
+
+// putAll adds or replaces several blocks and reindexes the tree once
+func (t *BlockTree) putAll(blocks []Block) {
+	for _, b := range blocks {
+		if _, exists := t.blocks[b.ID]; !exists {
+			t.order = append(t.order, b.ID)
+		}
+		t.blocks[b.ID] = b
+	}
+	t.reindex()
+}
+
+// Remove removes a single block from the tree and reports whether it existed.
+// Its children are not removed and become roots unless they have another parent in the tree.
+func (t *BlockTree) Remove(id uuid.UUID) bool {
+	if _, exists := t.blocks[id]; !exists {
+		return false
+	}
+	delete(t.blocks, id)
+	for i, orderedID := range t.order {
+		if orderedID == id {
+			t.order = append(t.order[:i], t.order[i+1:]...)
+			break
+		}
+	}
+	t.reindex()
+	return true
+}
+
+// Parent returns the parent of the block, if the parent is part of the tree
+func (t *BlockTree) Parent(id uuid.UUID) (Block, bool) {
+	parentID, exists := t.parents[id]
+	if !exists {
+		return Block{}, false
+	}
+	return t.Get(parentID)
+}
+
+// Children returns the direct children of the block in Content order
+func (t *BlockTree) Children(id uuid.UUID) []Block {
+	return t.collect(t.children[id])
+}
+
+// Roots returns all blocks that have no parent in the tree
+func (t *BlockTree) Roots() []Block {
+	return t.collect(t.roots)
+}
+
+// Root returns the topmost ancestor of the block, or the block itself if it is a root
+func (t *BlockTree) Root(id uuid.UUID) (Block, bool) {
+	if !t.Has(id) {
+		return Block{}, false
+	}
+	ancestors := t.Ancestors(id)
+	if len(ancestors) == 0 {
+		return t.Get(id)
+	}
+	return ancestors[len(ancestors)-1], true
+}
+
+// Ancestors returns the ancestors of the block, starting with its parent.
+// The walk stops if a cycle is detected.
+func (t *BlockTree) Ancestors(id uuid.UUID) []Block {
+	return t.collect(t.ancestorIDs(id))
+}
+
+// ancestorIDs walks the parents index upwards, stopping at the first block already visited
+func (t *BlockTree) ancestorIDs(id uuid.UUID) []uuid.UUID {
+	var ids []uuid.UUID
+	visited := map[uuid.UUID]bool{id: true}
+
+	current := id
+	for {
+		parentID, exists := t.parents[current]
+		if !exists || visited[parentID] {
+			return ids
+		}
+		visited[parentID] = true
+		ids = append(ids, parentID)
+		current = parentID
+	}
+}
+
+// Siblings returns the other children of the block's parent in Content order.
+// For roots, the other roots are returned.
+func (t *BlockTree) Siblings(id uuid.UUID) []Block {
+	if !t.Has(id) {
+		return nil
+	}
+
+	siblingIDs := t.roots
+	if parentID, exists := t.parents[id]; exists {
+		siblingIDs = t.children[parentID]
+	}
+
+	var siblings []Block
+	for _, siblingID := range siblingIDs {
+		if siblingID != id {
+			siblings = append(siblings, t.blocks[siblingID])
+		}
+	}
+	return siblings
+}
+
+// Depth returns the number of ancestors of the block: 0 for roots and -1 for unknown blocks
+func (t *BlockTree) Depth(id uuid.UUID) int {
+	if !t.Has(id) {
+		return -1
+	}
+	return len(t.Ancestors(id))
+}
+
+// Descendants returns all descendants of the block in depth-first (document) order
+func (t *BlockTree) Descendants(id uuid.UUID) []Block {
+	return t.collect(t.descendantIDs(id))
+}
+
+// descendantIDs walks the children index depth-first, ignoring blocks already visited
+func (t *BlockTree) descendantIDs(id uuid.UUID) []uuid.UUID {
+	var ids []uuid.UUID
+	visited := map[uuid.UUID]bool{id: true}
+
+	var walk func(parentID uuid.UUID)
+	walk = func(parentID uuid.UUID) {
+		for _, childID := range t.children[parentID] {
+			if visited[childID] {
+				continue
+			}
+			visited[childID] = true
+			ids = append(ids, childID)
+			walk(childID)
+		}
+	}
+	walk(id)
+
+	return ids
+}
+
+// Subtree returns a new tree with the block and all its descendants
+func (t *BlockTree) Subtree(id uuid.UUID) (*BlockTree, error) {
+	b, exists := t.Get(id)
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrBlockNotFound, id)
+	}
+	return NewBlockTree(append([]Block{b}, t.Descendants(id)...))
+}
+
+// Verify checks that ParentID and Content agree for every block in the tree and that
+// the hierarchy has no cycles. It returns ErrInconsistentTree describing every problem found.
+func (t *BlockTree) Verify() error {
+	var problems []error
+
+	for _, id := range t.order {
+		b := t.blocks[id]
+
+		for _, childID := range t.children[id] {
+			child := t.blocks[childID]
+			if child.ParentID == nil || *child.ParentID != id {
+				problems = append(problems, fmt.Errorf("block %s is in the content of %s but has a different parent", childID, id))
+			}
+		}
+
+		if parentID, exists := t.parents[id]; exists && !containsID(t.blocks[parentID].Content, id) {
+			problems = append(problems, fmt.Errorf("block %s has parent %s but is missing from its content", id, parentID))
+		}
+
+		if b.ParentID != nil && t.isOwnAncestor(id) {
+			problems = append(problems, fmt.Errorf("block %s is its own ancestor", id))
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrInconsistentTree, errors.Join(problems...))
+}
+
+// isOwnAncestor reports whether following ParentID from the block leads back to it
+func (t *BlockTree) isOwnAncestor(id uuid.UUID) bool {
+	visited := map[uuid.UUID]bool{}
+	current := id
+	for {
+		parentID, exists := t.parents[current]
+		if !exists {
+			return false
+		}
+		if parentID == id {
+			return true
+		}
+		if visited[parentID] {
+			// Cycle that does not include this block
+			return false
+		}
+		visited[parentID] = true
+		current = parentID
+	}
+}
+
+func (t *BlockTree) collect(ids []uuid.UUID) []Block {
+	blocks := make([]Block, 0, len(ids))
+	for _, id := range ids {
+		blocks = append(blocks, t.blocks[id])
+	}
+	return blocks
+}
+
+// containsID reports whether the list contains the given ID
+func containsID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
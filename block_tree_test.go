@@ -0,0 +1,154 @@
+package blocks
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestTreeBlocks builds a page with two children, the first of which has two children:
+//
+//	page
+//	├── a
+//	│   ├── a1
+//	│   └── a2
+//	└── b
+func newTestTreeBlocks() (page, a, a1, a2, b Block) {
+	page = NewEmptyBlock()
+	page.Type = TypePage
+
+	a = page.CreateChild()
+	b = page.CreateChild()
+	a1 = a.CreateChild()
+	a2 = a.CreateChild()
+
+	page.Content = []uuid.UUID{a.ID, b.ID}
+	page.ChildrenRecursive = []uuid.UUID{a.ID, a1.ID, a2.ID, b.ID}
+	a.Content = []uuid.UUID{a1.ID, a2.ID}
+	a.ChildrenRecursive = []uuid.UUID{a1.ID, a2.ID}
+
+	return page, a, a1, a2, b
+}
+
+func blockIDs(blocks []Block) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(blocks))
+	for _, b := range blocks {
+		ids = append(ids, b.ID)
+	}
+	return ids
+}
+
+func TestBlockTree(t *testing.T) {
+	page, a, a1, a2, b := newTestTreeBlocks()
+
+	tree, err := NewBlockTree([]Block{a2, b, page, a1, a})
+	assert.NoError(t, err)
+	assert.NoError(t, tree.Verify())
+
+	t.Run("indexes", func(t *testing.T) {
+		assert.Equal(t, 5, tree.Len())
+		assert.Equal(t, []uuid.UUID{page.ID}, blockIDs(tree.Roots()))
+		assert.Equal(t, []uuid.UUID{a.ID, b.ID}, blockIDs(tree.Children(page.ID)))
+		assert.Equal(t, []uuid.UUID{a1.ID, a2.ID}, blockIDs(tree.Children(a.ID)))
+
+		parent, ok := tree.Parent(a1.ID)
+		assert.True(t, ok)
+		assert.Equal(t, a.ID, parent.ID)
+
+		_, ok = tree.Parent(page.ID)
+		assert.False(t, ok)
+
+		assert.Len(t, tree.Lookup(), 5)
+	})
+
+	t.Run("queries", func(t *testing.T) {
+		assert.Equal(t, []uuid.UUID{a.ID, page.ID}, blockIDs(tree.Ancestors(a2.ID)))
+		assert.Equal(t, []uuid.UUID{a1.ID}, blockIDs(tree.Siblings(a2.ID)))
+		assert.Equal(t, []uuid.UUID{a.ID}, blockIDs(tree.Siblings(b.ID)))
+		assert.Equal(t, 0, tree.Depth(page.ID))
+		assert.Equal(t, 2, tree.Depth(a1.ID))
+		assert.Equal(t, -1, tree.Depth(uuid.New()))
+		assert.Equal(t, []uuid.UUID{a.ID, a1.ID, a2.ID, b.ID}, blockIDs(tree.Descendants(page.ID)))
+
+		root, ok := tree.Root(a2.ID)
+		assert.True(t, ok)
+		assert.Equal(t, page.ID, root.ID)
+	})
+
+	t.Run("subtree", func(t *testing.T) {
+		subtree, err := tree.Subtree(a.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, subtree.Len())
+		assert.Equal(t, []uuid.UUID{a.ID}, blockIDs(subtree.Roots()))
+
+		_, err = tree.Subtree(uuid.New())
+		assert.ErrorIs(t, err, ErrBlockNotFound)
+	})
+
+	t.Run("duplicate ids", func(t *testing.T) {
+		_, err := NewBlockTree([]Block{page, page})
+		assert.ErrorIs(t, err, ErrDuplicateBlockID)
+	})
+
+	t.Run("put and remove reindex", func(t *testing.T) {
+		tree, err := NewBlockTree([]Block{page, a, a1, a2, b})
+		assert.NoError(t, err)
+
+		assert.True(t, tree.Remove(a.ID))
+		assert.ElementsMatch(t, []uuid.UUID{page.ID, a1.ID, a2.ID}, blockIDs(tree.Roots()))
+
+		tree.Put(a)
+		assert.Equal(t, []uuid.UUID{page.ID}, blockIDs(tree.Roots()))
+	})
+
+	t.Run("put matches a full build", func(t *testing.T) {
+		// Children before their parents, a replaced block and a block whose parent never arrives
+		missingParent := uuid.New()
+		orphan := NewEmptyBlock()
+		orphan.ParentID = &missingParent
+		movedB := b
+		movedB.ParentID = &a.ID
+		movedA := a
+		movedA.Content = []uuid.UUID{a1.ID, a2.ID, b.ID}
+		movedPage := page
+		movedPage.Content = []uuid.UUID{a.ID}
+		puts := []Block{a2, orphan, a1, b, a, page, movedB, movedA, movedPage}
+
+		tree, err := NewBlockTree(nil)
+		assert.NoError(t, err)
+		for _, put := range puts {
+			tree.Put(put)
+		}
+
+		expected, err := NewBlockTree([]Block{a2, orphan, a1, movedB, movedA, movedPage})
+		assert.NoError(t, err)
+		assert.Equal(t, blockIDs(expected.Blocks()), blockIDs(tree.Blocks()))
+		assert.Equal(t, blockIDs(expected.Roots()), blockIDs(tree.Roots()))
+		assert.Equal(t, []uuid.UUID{orphan.ID, page.ID}, blockIDs(tree.Roots()))
+		for _, id := range []uuid.UUID{page.ID, a.ID, a1.ID, a2.ID, b.ID, orphan.ID} {
+			assert.Equal(t, blockIDs(expected.Children(id)), blockIDs(tree.Children(id)))
+			assert.Equal(t, blockIDs(expected.Ancestors(id)), blockIDs(tree.Ancestors(id)))
+		}
+		assert.Equal(t, []uuid.UUID{a.ID, page.ID}, blockIDs(tree.Ancestors(b.ID)))
+		assert.NoError(t, tree.Verify())
+	})
+
+	t.Run("verify detects inconsistencies", func(t *testing.T) {
+		orphanedPage := page
+		orphanedPage.Content = []uuid.UUID{b.ID}
+
+		tree, err := NewBlockTree([]Block{orphanedPage, a, b})
+		assert.NoError(t, err)
+		assert.ErrorIs(t, tree.Verify(), ErrInconsistentTree)
+
+		x, y := NewEmptyBlock(), NewEmptyBlock()
+		x.ParentID, y.ParentID = &y.ID, &x.ID
+		x.Content, y.Content = []uuid.UUID{y.ID}, []uuid.UUID{x.ID}
+
+		tree, err = NewBlockTree([]Block{x, y})
+		assert.NoError(t, err)
+		assert.Empty(t, tree.Roots())
+		assert.ErrorContains(t, tree.Verify(), "its own ancestor")
+	})
+}
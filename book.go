@@ -313,3 +313,14 @@ func GetBookProperties() []string {
 		PropertyKeyEnriched,
 	}
 }
+
+func init() {
+	mustRegisterType(TypeDefinition{
+		DataType:     TypeBook,
+		RenderFunc:   renderWith(RenderBookProperties),
+		PropertyKeys: GetBookProperties(),
+		Content:      BlockContentTypeStructural,
+		Final:        true,
+		Schema:       NewPropertySchema(GetBookProperties()...).Require(PropertyKeyTitle),
+	})
+}
@@ -37,3 +37,12 @@ func GetBulletListItemProperties() []string {
 		PropertyKeyTitle,
 	}
 }
+
+func init() {
+	mustRegisterType(TypeDefinition{
+		DataType:     TypeBulletListItem,
+		RenderFunc:   renderWith(RenderBulletListItemProperties),
+		PropertyKeys: GetBulletListItemProperties(),
+		Content:      BlockContentTypeTextual,
+	})
+}
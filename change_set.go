@@ -0,0 +1,184 @@
+package blocks
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// FieldChange is the old and new value of a block field
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// PropertyChange is the old and new values of a single property key.
+// Old is nil for added keys and New is nil for removed keys.
+type PropertyChange struct {
+	Key string        `json:"key"`
+	Old []interface{} `json:"old"`
+	New []interface{} `json:"new"`
+}
+
+// Added reports whether the property did not exist before
+func (c PropertyChange) Added() bool {
+	return c.Old == nil && c.New != nil
+}
+
+// Removed reports whether the property no longer exists
+func (c PropertyChange) Removed() bool {
+	return c.New == nil && c.Old != nil
+}
+
+// ChangeSet lists everything that changed between two versions of a block.
+// Changes of properties and styles are listed per key instead of in Fields.
+type ChangeSet struct {
+	BlockID    uuid.UUID        `json:"block_id"`
+	Fields     []FieldChange    `json:"fields"`
+	Properties []PropertyChange `json:"properties"`
+	Styles     []PropertyChange `json:"styles"`
+}
+
+// IsEmpty reports whether nothing changed
+func (c ChangeSet) IsEmpty() bool {
+	return len(c.Fields) == 0 && len(c.Properties) == 0 && len(c.Styles) == 0
+}
+
+// Field returns the change of the field with the given JSON name
+func (c ChangeSet) Field(name string) (FieldChange, bool) {
+	for _, change := range c.Fields {
+		if change.Field == name {
+			return change, true
+		}
+	}
+	return FieldChange{}, false
+}
+
+// Property returns the change of the property key
+func (c ChangeSet) Property(key string) (PropertyChange, bool) {
+	for _, change := range c.Properties {
+		if change.Key == key {
+			return change, true
+		}
+	}
+	return PropertyChange{}, false
+}
+
+// FieldNames returns the JSON names of all changed fields in the order UpdateFromJSON reports them:
+// struct order, with updated_at last
+func (c ChangeSet) FieldNames() []string {
+	changed := make(map[string]bool, len(c.Fields)+2)
+	for _, change := range c.Fields {
+		changed[change.Field] = true
+	}
+	changed[BlockPropertyProperties] = len(c.Properties) > 0
+	changed[BlockPropertyStypes] = len(c.Styles) > 0
+
+	names := make([]string, 0, len(changed))
+	t := reflect.TypeOf(Block{})
+	for i := 0; i < t.NumField(); i++ {
+		if name := getJSONFieldName(t.Field(i)); changed[name] && name != BlockPropertyUpdatedAt {
+			names = append(names, name)
+		}
+	}
+	if changed[BlockPropertyUpdatedAt] {
+		names = append(names, BlockPropertyUpdatedAt)
+	}
+	return names
+}
+
+// PropertyKeys returns the changed property keys, sorted
+func (c ChangeSet) PropertyKeys() []string {
+	keys := make([]string, 0, len(c.Properties))
+	for _, change := range c.Properties {
+		keys = append(keys, change.Key)
+	}
+	return keys
+}
+
+// DiffBlocks returns the changes from the version before to the version after an update.
+// The values in the change set are copies and are not shared with the blocks.
+func DiffBlocks(before, after Block) ChangeSet {
+	before, after = before.Copy(), after.Copy()
+	changes := ChangeSet{BlockID: after.ID}
+
+	oldValue := reflect.ValueOf(before)
+	newValue := reflect.ValueOf(after)
+	t := oldValue.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		name := getJSONFieldName(t.Field(i))
+		switch name {
+		case BlockPropertyProperties:
+			changes.Properties = diffProperties(before.Properties, after.Properties)
+		case BlockPropertyStypes:
+			changes.Styles = diffProperties(before.Styles, after.Styles)
+		default:
+			if !equalFieldValues(oldValue.Field(i), newValue.Field(i)) {
+				changes.Fields = append(changes.Fields, FieldChange{
+					Field: name,
+					Old:   oldValue.Field(i).Interface(),
+					New:   newValue.Field(i).Interface(),
+				})
+			}
+		}
+	}
+
+	return changes
+}
+
+// diffProperties compares two property maps key by key, in key order
+func diffProperties(before, after Properties) []PropertyChange {
+	keys := make(map[string]bool, len(before)+len(after))
+	for key := range before {
+		keys[key] = true
+	}
+	for key := range after {
+		keys[key] = true
+	}
+
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	var changes []PropertyChange
+	for _, key := range sorted {
+		oldValues, oldExists := before[key]
+		newValues, newExists := after[key]
+		if oldExists && newExists && reflect.DeepEqual(oldValues, newValues) {
+			continue
+		}
+
+		change := PropertyChange{Key: key}
+		if oldExists {
+			change.Old = nonNilValues(oldValues)
+		}
+		if newExists {
+			change.New = nonNilValues(newValues)
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// nonNilValues keeps existing but empty properties distinguishable from missing ones
+func nonNilValues(values []interface{}) []interface{} {
+	if values == nil {
+		return []interface{}{}
+	}
+	return values
+}
+
+// UpdateFromJSONWithChanges works like UpdateFromJSON but returns the old and new value of every changed field
+// and property key
+func (b *Block) UpdateFromJSONWithChanges(data []byte) (ChangeSet, error) {
+	before := b.Copy()
+	if _, err := b.UpdateFromJSON(data); err != nil {
+		return ChangeSet{}, err
+	}
+	return DiffBlocks(before, *b), nil
+}
@@ -0,0 +1,83 @@
+package blocks
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateFromJSONWithChanges(t *testing.T) {
+	t.Run("field and property changes", func(t *testing.T) {
+		parentID := uuid.New()
+		b := NewEmptyBlock()
+		b.Type = TypeMovie
+		b.RawBody = "Old body"
+		b.Properties[PropertyKeyTitle] = []interface{}{"The Matrix"}
+		b.Properties[PropertyKeyRating] = []interface{}{"8.7"}
+		b.Styles["color"] = []interface{}{"red"}
+
+		changes, err := b.UpdateFromJSONWithChanges([]byte(`{
+			"parent_id": "` + parentID.String() + `",
+			"raw_body": "New body",
+			"properties": {"title": ["The Matrix Reloaded"], "rating": null, "release_year": [2003]},
+			"styles": {"color": ["red"]},
+			"meaning": "A sequel"
+		}`))
+
+		assert.NoError(t, err)
+		assert.Equal(t, b.ID, changes.BlockID)
+		assert.Equal(t, []string{"parent_id", "properties", "raw_body", "meaning", "updated_at"}, changes.FieldNames())
+		assert.Equal(t, []string{"rating", "release_year", "title"}, changes.PropertyKeys())
+		assert.Empty(t, changes.Styles)
+
+		parentChange, ok := changes.Field(BlockPropertyParentID)
+		assert.True(t, ok)
+		assert.Nil(t, parentChange.Old)
+		assert.Equal(t, &parentID, parentChange.New)
+
+		bodyChange, _ := changes.Field(BlockPropertyRawBody)
+		assert.Equal(t, FieldChange{Field: "raw_body", Old: "Old body", New: "New body"}, bodyChange)
+
+		title, _ := changes.Property(PropertyKeyTitle)
+		assert.Equal(t, []interface{}{"The Matrix"}, title.Old)
+		assert.Equal(t, []interface{}{"The Matrix Reloaded"}, title.New)
+
+		rating, _ := changes.Property(PropertyKeyRating)
+		assert.True(t, rating.Removed())
+		year, _ := changes.Property(PropertyKeyReleaseYear)
+		assert.True(t, year.Added())
+		assert.Equal(t, []interface{}{2003}, year.New)
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		b := NewEmptyBlock()
+		b.RawBody = "Body"
+
+		changes, err := b.UpdateFromJSONWithChanges([]byte(`{"raw_body": "Body"}`))
+		assert.NoError(t, err)
+		assert.True(t, changes.IsEmpty())
+		assert.Empty(t, changes.FieldNames())
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		b := NewEmptyBlock()
+
+		_, err := b.UpdateFromJSONWithChanges([]byte(`{invalid`))
+		assert.Error(t, err)
+	})
+
+	t.Run("values are not shared with the block", func(t *testing.T) {
+		before := NewEmptyBlock()
+		before.Content = []uuid.UUID{uuid.New()}
+		after := before.Copy()
+		after.Content = append(after.Content, uuid.New())
+
+		changes := DiffBlocks(before, after)
+		after.Content[0] = uuid.Nil
+
+		contentChange, ok := changes.Field(BlockPropertyContent)
+		assert.True(t, ok)
+		assert.NotEqual(t, uuid.Nil, contentChange.New.([]uuid.UUID)[0])
+	})
+}
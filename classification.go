@@ -1,5 +1,285 @@
 package blocks
 
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
 // Classification represents the classification data for a block
 // Contains class names mapped to their accuracy scores
 type Classification map[string]float64
+
+// ClassScore is a single class with its score
+type ClassScore struct {
+	Class string  `json:"class"`
+	Score float64 `json:"score"`
+}
+
+// Copy returns a copy of the classification
+func (c Classification) Copy() Classification {
+	if c == nil {
+		return nil
+	}
+	copied := make(Classification, len(c))
+	for class, score := range c {
+		copied[class] = score
+	}
+	return copied
+}
+
+// Ranked returns all classes ordered by descending score, ties ordered by class name
+func (c Classification) Ranked() []ClassScore {
+	ranked := make([]ClassScore, 0, len(c))
+	for class, score := range c {
+		ranked = append(ranked, ClassScore{Class: class, Score: score})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].Class < ranked[j].Class
+	})
+	return ranked
+}
+
+// Top returns the k classes with the highest scores. All classes are returned if k is not positive.
+func (c Classification) Top(k int) []ClassScore {
+	ranked := c.Ranked()
+	if k > 0 && k < len(ranked) {
+		return ranked[:k]
+	}
+	return ranked
+}
+
+// Best returns the class with the highest score
+func (c Classification) Best() (ClassScore, bool) {
+	top := c.Top(1)
+	if len(top) == 0 {
+		return ClassScore{}, false
+	}
+	return top[0], true
+}
+
+// Threshold returns the classes with a score of at least min
+func (c Classification) Threshold(min float64) Classification {
+	filtered := make(Classification)
+	for class, score := range c {
+		if score >= min {
+			filtered[class] = score
+		}
+	}
+	return filtered
+}
+
+// Normalize returns the classification scaled so the scores sum to 1.
+// Negative scores are treated as 0. If no score is positive, the classes are returned with score 0.
+func (c Classification) Normalize() Classification {
+	var total float64
+	for _, score := range c {
+		if score > 0 {
+			total += score
+		}
+	}
+
+	normalized := make(Classification, len(c))
+	for class, score := range c {
+		if score <= 0 || total == 0 {
+			normalized[class] = 0
+			continue
+		}
+		normalized[class] = score / total
+	}
+	return normalized
+}
+
+// WeightedClassification is the output of a single classifier together with the weight it gets when merged
+type WeightedClassification struct {
+	Classification Classification
+	Weight         float64
+}
+
+// MergeClassifications combines the output of several classifiers into their weighted average.
+// A class missing from a classification counts as a score of 0. Classifications without a positive weight are ignored.
+func MergeClassifications(inputs ...WeightedClassification) Classification {
+	merged := make(Classification)
+
+	var totalWeight float64
+	for _, input := range inputs {
+		if input.Weight <= 0 {
+			continue
+		}
+		totalWeight += input.Weight
+		for class, score := range input.Classification {
+			merged[class] += score * input.Weight
+		}
+	}
+
+	if totalWeight == 0 {
+		return merged
+	}
+	for class := range merged {
+		merged[class] /= totalWeight
+	}
+	return merged
+}
+
+// TaxonomySeparator separates the classes in a taxonomy path
+const TaxonomySeparator = "/"
+
+// RollUpMode defines how the scores of child classes are combined into their parent class
+type RollUpMode string
+
+const (
+	// RollUpSum adds the scores of the children to the parent, suitable for normalized classifications
+	RollUpSum RollUpMode = "sum"
+	// RollUpMax gives the parent the highest score of itself and its children
+	RollUpMax RollUpMode = "max"
+)
+
+// Taxonomy is a hierarchy of classes, e.g. media/video/movie, used to roll scores up to parent classes.
+// Classes are identified by their full path. A class can also be referred to by its own name, e.g. movie,
+// as long as no other class in the taxonomy has the same name.
+type Taxonomy struct {
+	parents  map[string]string
+	children map[string][]string
+	names    map[string][]string
+	order    []string
+}
+
+// NewTaxonomy creates a taxonomy from paths like "media/video/movie"
+func NewTaxonomy(paths ...string) (*Taxonomy, error) {
+	t := &Taxonomy{
+		parents:  make(map[string]string),
+		children: make(map[string][]string),
+		names:    make(map[string][]string),
+	}
+	for _, path := range paths {
+		if err := t.Add(path); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// Add adds the class of the path and all its ancestors to the taxonomy
+func (t *Taxonomy) Add(path string) error {
+	classes := strings.Split(strings.Trim(path, TaxonomySeparator), TaxonomySeparator)
+
+	parent := ""
+	for _, class := range classes {
+		class = strings.TrimSpace(class)
+		if class == "" {
+			return fmt.Errorf("%w: empty class in %q", ErrInvalidTaxonomy, path)
+		}
+
+		current := class
+		if parent != "" {
+			current = parent + TaxonomySeparator + class
+		}
+		if _, exists := t.parents[current]; !exists {
+			t.parents[current] = parent
+			t.names[class] = append(t.names[class], current)
+			t.order = append(t.order, current)
+			if parent != "" {
+				t.children[parent] = append(t.children[parent], current)
+			}
+		}
+		parent = current
+	}
+	return nil
+}
+
+// Resolve returns the full path of a class given by its path or by its name.
+// It returns false for unknown classes and for names shared by several classes.
+func (t *Taxonomy) Resolve(class string) (string, bool) {
+	path := strings.Trim(class, TaxonomySeparator)
+	if _, exists := t.parents[path]; exists {
+		return path, true
+	}
+	if paths := t.names[path]; len(paths) == 1 {
+		return paths[0], true
+	}
+	return "", false
+}
+
+// Has reports whether the class resolves to a class of the taxonomy
+func (t *Taxonomy) Has(class string) bool {
+	_, ok := t.Resolve(class)
+	return ok
+}
+
+// Parent returns the full path of the parent of the class, if it has one
+func (t *Taxonomy) Parent(class string) (string, bool) {
+	path, ok := t.Resolve(class)
+	if !ok {
+		return "", false
+	}
+	parent := t.parents[path]
+	return parent, parent != ""
+}
+
+// Children returns the full paths of the direct children of the class in the order they were added
+func (t *Taxonomy) Children(class string) []string {
+	path, ok := t.Resolve(class)
+	if !ok {
+		return []string{}
+	}
+	return append([]string{}, t.children[path]...)
+}
+
+// Roots returns the classes without a parent in the order they were added
+func (t *Taxonomy) Roots() []string {
+	var roots []string
+	for _, path := range t.order {
+		if t.parents[path] == "" {
+			roots = append(roots, path)
+		}
+	}
+	return roots
+}
+
+// Ancestors returns the full paths of the ancestors of the class, starting with its parent
+func (t *Taxonomy) Ancestors(class string) []string {
+	var ancestors []string
+	for parent, ok := t.Parent(class); ok; parent, ok = t.Parent(parent) {
+		ancestors = append(ancestors, parent)
+	}
+	return ancestors
+}
+
+// Path returns the full path of the class, e.g. "media/video/movie", or the class itself if it does not resolve
+func (t *Taxonomy) Path(class string) string {
+	if path, ok := t.Resolve(class); ok {
+		return path
+	}
+	return class
+}
+
+// RollUp returns the classification keyed by full path with the scores of every class propagated to all its
+// ancestors. Input classes may be given by path or by name. Classes that do not resolve keep their key and score.
+func (t *Taxonomy) RollUp(c Classification, mode RollUpMode) Classification {
+	own := make(Classification, len(c))
+	for class, score := range c {
+		own[t.Path(class)] += score
+	}
+
+	rolled := own.Copy()
+	switch mode {
+	case RollUpMax:
+		for class, score := range own {
+			for _, ancestor := range t.Ancestors(class) {
+				if current, exists := rolled[ancestor]; !exists || score > current {
+					rolled[ancestor] = score
+				}
+			}
+		}
+	default:
+		for class, score := range own {
+			for _, ancestor := range t.Ancestors(class) {
+				rolled[ancestor] += score
+			}
+		}
+	}
+	return rolled
+}
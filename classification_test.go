@@ -0,0 +1,113 @@
+package blocks
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassification(t *testing.T) {
+	c := Classification{"movie": 0.6, "series": 0.2, "book": 0.2, "note": 0}
+
+	t.Run("top and best", func(t *testing.T) {
+		assert.Equal(t, []ClassScore{{"movie", 0.6}, {"book", 0.2}}, c.Top(2))
+		assert.Len(t, c.Top(0), 4)
+		assert.Len(t, c.Top(10), 4)
+
+		best, ok := c.Best()
+		assert.True(t, ok)
+		assert.Equal(t, ClassScore{"movie", 0.6}, best)
+
+		_, ok = Classification{}.Best()
+		assert.False(t, ok)
+	})
+
+	t.Run("threshold", func(t *testing.T) {
+		assert.Equal(t, Classification{"movie": 0.6, "series": 0.2, "book": 0.2}, c.Threshold(0.2))
+		assert.Empty(t, c.Threshold(0.9))
+	})
+
+	t.Run("normalize", func(t *testing.T) {
+		normalized := Classification{"movie": 3, "book": 1, "spam": -1}.Normalize()
+		assert.Equal(t, Classification{"movie": 0.75, "book": 0.25, "spam": 0}, normalized)
+
+		assert.Equal(t, Classification{"movie": 0}, Classification{"movie": 0}.Normalize())
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		merged := MergeClassifications(
+			WeightedClassification{Classification: Classification{"movie": 0.8, "book": 0.2}, Weight: 3},
+			WeightedClassification{Classification: Classification{"movie": 0.4, "series": 0.6}, Weight: 1},
+			WeightedClassification{Classification: Classification{"spam": 1}, Weight: 0},
+		)
+		assert.InDelta(t, 0.7, merged["movie"], 1e-9)
+		assert.InDelta(t, 0.15, merged["book"], 1e-9)
+		assert.InDelta(t, 0.15, merged["series"], 1e-9)
+		assert.NotContains(t, merged, "spam")
+
+		assert.Empty(t, MergeClassifications())
+	})
+}
+
+func TestTaxonomy(t *testing.T) {
+	taxonomy, err := NewTaxonomy("media/video/movie", "media/video/series", "media/book", "task")
+	assert.NoError(t, err)
+
+	t.Run("hierarchy", func(t *testing.T) {
+		assert.True(t, taxonomy.Has("video"))
+		assert.True(t, taxonomy.Has("media/video"))
+		assert.Equal(t, []string{"media", "task"}, taxonomy.Roots())
+		assert.Equal(t, []string{"media/video", "media/book"}, taxonomy.Children("media"))
+		assert.Equal(t, []string{"media/video", "media"}, taxonomy.Ancestors("movie"))
+		assert.Equal(t, []string{"media/video", "media"}, taxonomy.Ancestors("media/video/movie"))
+		assert.Equal(t, "media/video/series", taxonomy.Path("series"))
+		assert.Equal(t, "unknown", taxonomy.Path("unknown"))
+
+		_, ok := taxonomy.Parent("media")
+		assert.False(t, ok)
+	})
+
+	t.Run("same name under different parents", func(t *testing.T) {
+		taxonomy, err := NewTaxonomy("media/video/movie", "cinema/movie")
+		assert.NoError(t, err)
+
+		_, ok := taxonomy.Resolve("movie")
+		assert.False(t, ok)
+		assert.Equal(t, []string{"cinema"}, taxonomy.Ancestors("cinema/movie"))
+
+		rolled := taxonomy.RollUp(Classification{"cinema/movie": 0.5, "media/video/movie": 0.25, "movie": 0.25}, RollUpSum)
+		assert.Equal(t, Classification{
+			"cinema/movie": 0.5, "cinema": 0.5,
+			"media/video/movie": 0.25, "media/video": 0.25, "media": 0.25,
+			"movie": 0.25,
+		}, rolled)
+
+		_, err = NewTaxonomy("media//movie")
+		assert.ErrorIs(t, err, ErrInvalidTaxonomy)
+	})
+
+	t.Run("roll up", func(t *testing.T) {
+		c := Classification{"movie": 0.5, "series": 0.25, "book": 0.125, "spam": 0.125}
+
+		summed := taxonomy.RollUp(c, RollUpSum)
+		assert.Equal(t, 0.75, summed["media/video"])
+		assert.Equal(t, 0.875, summed["media"])
+		assert.Equal(t, 0.125, summed["spam"])
+		assert.Equal(t, 0.5, summed["media/video/movie"])
+		assert.NotContains(t, summed, "movie")
+
+		maxed := taxonomy.RollUp(c, RollUpMax)
+		assert.Equal(t, 0.5, maxed["media/video"])
+		assert.Equal(t, 0.5, maxed["media"])
+		assert.NotContains(t, maxed, "task")
+	})
+
+	t.Run("roll up path keys", func(t *testing.T) {
+		c := Classification{"media/video/movie": 0.5, "media/video/series": 0.25, "/media/book": 0.25}
+
+		assert.Equal(t, Classification{
+			"media/video/movie": 0.5, "media/video/series": 0.25, "media/book": 0.25,
+			"media/video": 0.75, "media": 1,
+		}, taxonomy.RollUp(c, RollUpSum))
+	})
+}
@@ -0,0 +1,307 @@
+package blocks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// DefaultRuleWeight is the weight of the keyword rules relative to the model: a matching rule shifts the
+// scores towards its class without overruling a confident model
+const DefaultRuleWeight = 0.5
+
+// Classifier produces a Classification for a block
+type Classifier interface {
+	Classify(ctx context.Context, b Block) (Classification, error)
+}
+
+// TrainingExample is a block labeled with the class it belongs to
+type TrainingExample struct {
+	Block Block
+	Class string
+}
+
+// TrainingExamplesFromBlocks labels every block with the best class of its Classification.
+// Blocks without a class scoring at least minScore are skipped.
+func TrainingExamplesFromBlocks(blocks []Block, minScore float64) []TrainingExample {
+	var examples []TrainingExample
+	for _, b := range blocks {
+		best, ok := b.Classification.Best()
+		if !ok || best.Score < minScore {
+			continue
+		}
+		examples = append(examples, TrainingExample{Block: b, Class: best.Class})
+	}
+	return examples
+}
+
+// KeywordRule assigns a class to blocks containing any of its keywords.
+// Keywords match words of the rendered properties and the block Keywords, case-insensitively.
+// If Types is set, the rule only applies to blocks of those types.
+type KeywordRule struct {
+	Class    string     `json:"class"`
+	Keywords []string   `json:"keywords"`
+	Types    []DataType `json:"types,omitempty"`
+	// Score is the confidence in the class when the rule matches, between 0 and 1; 1 if not set
+	Score float64 `json:"score,omitempty"`
+}
+
+// matches reports whether the rule applies to a block with the given type and words
+func (r KeywordRule) matches(dataType DataType, words map[string]bool) bool {
+	if len(r.Types) > 0 {
+		typeMatches := false
+		for _, t := range r.Types {
+			if t == dataType {
+				typeMatches = true
+				break
+			}
+		}
+		if !typeMatches {
+			return false
+		}
+	}
+
+	for _, keyword := range r.Keywords {
+		tokens := tokenizeClassificationText(keyword)
+		if len(tokens) == 0 {
+			continue
+		}
+		found := true
+		for _, token := range tokens {
+			if !words[token] {
+				found = false
+				break
+			}
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+// NaiveBayesModel is a multinomial naive Bayes model over the words of blocks
+type NaiveBayesModel struct {
+	// Documents is the number of training examples per class
+	Documents map[string]int `json:"documents"`
+	// WordCounts counts the occurrences of every feature per class
+	WordCounts map[string]map[string]int `json:"word_counts"`
+	// TotalWords is the number of features seen per class
+	TotalWords map[string]int `json:"total_words"`
+	// Vocabulary is the number of distinct features seen in training
+	Vocabulary int `json:"vocabulary"`
+}
+
+// classify returns the posterior probability of every class for the features
+func (m *NaiveBayesModel) classify(features []string) Classification {
+	var totalDocuments int
+	for _, count := range m.Documents {
+		totalDocuments += count
+	}
+
+	logScores := make(map[string]float64, len(m.Documents))
+	maxLog := math.Inf(-1)
+	for class, documents := range m.Documents {
+		score := math.Log(float64(documents) / float64(totalDocuments))
+		denominator := float64(m.TotalWords[class] + m.Vocabulary)
+		for _, feature := range features {
+			// Laplace smoothing so unseen features do not zero the probability
+			score += math.Log(float64(m.WordCounts[class][feature]+1) / denominator)
+		}
+		logScores[class] = score
+		maxLog = math.Max(maxLog, score)
+	}
+
+	// Softmax relative to the highest score to avoid underflow
+	classification := make(Classification, len(logScores))
+	for class, score := range logScores {
+		classification[class] = math.Exp(score - maxLog)
+	}
+	return classification.Normalize()
+}
+
+// LocalClassifier classifies blocks offline using a naive Bayes model trained on labeled blocks
+// combined with user defined keyword rules. It can be saved to and loaded from a file.
+type LocalClassifier struct {
+	Model *NaiveBayesModel `json:"model,omitempty"`
+	Rules []KeywordRule    `json:"rules,omitempty"`
+	// RuleWeight is the weight of the rules relative to the model when both produce scores,
+	// DefaultRuleWeight if not set
+	RuleWeight float64 `json:"rule_weight,omitempty"`
+}
+
+// NewLocalClassifier creates a classifier with the given keyword rules and no trained model
+func NewLocalClassifier(rules ...KeywordRule) *LocalClassifier {
+	return &LocalClassifier{Rules: rules}
+}
+
+// Train replaces the model with one trained on the examples.
+// Features are the words of the rendered properties, the block Keywords and the block Type.
+func (c *LocalClassifier) Train(ctx context.Context, examples []TrainingExample) error {
+	if len(examples) == 0 {
+		return fmt.Errorf("%w: no training examples", ErrInvalidTrainingData)
+	}
+
+	model := &NaiveBayesModel{
+		Documents:  make(map[string]int),
+		WordCounts: make(map[string]map[string]int),
+		TotalWords: make(map[string]int),
+	}
+	vocabulary := make(map[string]bool)
+
+	for _, example := range examples {
+		if example.Class == "" {
+			return fmt.Errorf("%w: block %s has no class", ErrInvalidTrainingData, example.Block.ID)
+		}
+		model.Documents[example.Class]++
+		if model.WordCounts[example.Class] == nil {
+			model.WordCounts[example.Class] = make(map[string]int)
+		}
+		for _, feature := range classificationFeatures(ctx, example.Block) {
+			model.WordCounts[example.Class][feature]++
+			model.TotalWords[example.Class]++
+			vocabulary[feature] = true
+		}
+	}
+	model.Vocabulary = len(vocabulary)
+
+	c.Model = model
+	return nil
+}
+
+// Classify returns the class scores of the block. The model posterior and the scores of the matching rules are
+// merged using RuleWeight. It returns ErrClassifierNotTrained if there is neither a model nor any rule.
+func (c *LocalClassifier) Classify(ctx context.Context, b Block) (Classification, error) {
+	if c.Model == nil && len(c.Rules) == 0 {
+		return nil, ErrClassifierNotTrained
+	}
+
+	features := classificationFeatures(ctx, b)
+
+	var inputs []WeightedClassification
+	if c.Model != nil && len(c.Model.Documents) > 0 {
+		inputs = append(inputs, WeightedClassification{Classification: c.Model.classify(features), Weight: 1})
+	}
+
+	if ruleScores := c.classifyWithRules(b.Type, features); len(ruleScores) > 0 {
+		weight := c.RuleWeight
+		if weight <= 0 {
+			weight = DefaultRuleWeight
+		}
+		inputs = append(inputs, WeightedClassification{Classification: ruleScores, Weight: weight})
+	}
+
+	if len(inputs) == 1 {
+		return inputs[0].Classification, nil
+	}
+	return MergeClassifications(inputs...), nil
+}
+
+// classifyWithRules returns the highest score of the matching rules per class, clamped to [0,1].
+// When several classes match and their scores add up to more than 1, they are scaled to sum to 1
+// so the rules weigh the same as the normalized model posterior.
+func (c *LocalClassifier) classifyWithRules(dataType DataType, features []string) Classification {
+	words := make(map[string]bool, len(features))
+	for _, feature := range features {
+		words[feature] = true
+	}
+
+	scores := make(Classification)
+	for _, rule := range c.Rules {
+		if !rule.matches(dataType, words) {
+			continue
+		}
+		score := math.Min(rule.Score, 1)
+		if score <= 0 {
+			score = 1
+		}
+		if score > scores[rule.Class] {
+			scores[rule.Class] = score
+		}
+	}
+
+	var total float64
+	for _, score := range scores {
+		total += score
+	}
+	if total > 1 {
+		return scores.Normalize()
+	}
+	return scores
+}
+
+// Write writes the classifier as JSON
+func (c *LocalClassifier) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(c); err != nil {
+		return fmt.Errorf("failed to encode classifier: %w", err)
+	}
+	return nil
+}
+
+// Save writes the classifier to a file
+func (c *LocalClassifier) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create classifier file: %w", err)
+	}
+	if err := c.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadLocalClassifier reads a classifier written by Write
+func ReadLocalClassifier(r io.Reader) (*LocalClassifier, error) {
+	var c LocalClassifier
+	if err := json.NewDecoder(r).Decode(&c); err != nil {
+		return nil, fmt.Errorf("failed to decode classifier: %w", err)
+	}
+	return &c, nil
+}
+
+// LoadLocalClassifier reads a classifier saved with Save
+func LoadLocalClassifier(path string) (*LocalClassifier, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open classifier file: %w", err)
+	}
+	defer f.Close()
+	return ReadLocalClassifier(f)
+}
+
+// classificationFeatures returns the words of the rendered properties and keywords of the block, and its type
+func classificationFeatures(ctx context.Context, b Block) []string {
+	features := tokenizeClassificationText(RenderProperties(ctx, b))
+
+	for _, keyword := range b.Keywords() {
+		features = append(features, tokenizeClassificationText(keyword)...)
+	}
+
+	if b.Type != "" {
+		features = append(features, "type:"+string(b.Type))
+	}
+	return features
+}
+
+// tokenizeClassificationText splits the text into lower case words of at least two characters
+func tokenizeClassificationText(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	tokens := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if len([]rune(field)) >= 2 {
+			tokens = append(tokens, field)
+		}
+	}
+	return tokens
+}
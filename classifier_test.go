@@ -0,0 +1,131 @@
+package blocks
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalClassifier(t *testing.T) {
+	ctx := context.Background()
+
+	paragraph := func(title string) Block {
+		b := NewEmptyBlock()
+		b.Type = TypeParagraph
+		b.Properties[PropertyKeyTitle] = []interface{}{title}
+		return b
+	}
+
+	examples := []TrainingExample{
+		{Block: paragraph("chocolate cake recipe with flour and sugar"), Class: "recipe"},
+		{Block: paragraph("bake the bread with flour and yeast"), Class: "recipe"},
+		{Block: paragraph("pasta recipe with tomato sauce"), Class: "recipe"},
+		{Block: paragraph("quarterly budget meeting with finance"), Class: "work"},
+		{Block: paragraph("prepare slides for the client meeting"), Class: "work"},
+	}
+
+	t.Run("naive bayes", func(t *testing.T) {
+		classifier := NewLocalClassifier()
+		assert.NoError(t, classifier.Train(ctx, examples))
+
+		classification, err := classifier.Classify(ctx, paragraph("a cake with sugar"))
+		assert.NoError(t, err)
+		best, _ := classification.Best()
+		assert.Equal(t, "recipe", best.Class)
+		assert.InDelta(t, 1, classification["recipe"]+classification["work"], 1e-9)
+
+		classification, err = classifier.Classify(ctx, paragraph("meeting with the client"))
+		assert.NoError(t, err)
+		best, _ = classification.Best()
+		assert.Equal(t, "work", best.Class)
+	})
+
+	t.Run("keyword rules", func(t *testing.T) {
+		classifier := NewLocalClassifier(
+			KeywordRule{Class: "movie", Keywords: []string{"imdb", "box office"}, Score: 0.9},
+			KeywordRule{Class: "task", Keywords: []string{"todo"}, Types: []DataType{TypeToDo}},
+		)
+
+		classification, err := classifier.Classify(ctx, paragraph("Box office numbers for the weekend"))
+		assert.NoError(t, err)
+		assert.Equal(t, Classification{"movie": 0.9}, classification)
+
+		classification, err = classifier.Classify(ctx, paragraph("todo: call mum"))
+		assert.NoError(t, err)
+		assert.Empty(t, classification)
+	})
+
+	t.Run("rules and model are merged", func(t *testing.T) {
+		classifier := NewLocalClassifier(KeywordRule{Class: "work", Keywords: []string{"cake"}})
+		classifier.RuleWeight = 3
+		assert.NoError(t, classifier.Train(ctx, examples))
+
+		classification, err := classifier.Classify(ctx, paragraph("cake"))
+		assert.NoError(t, err)
+		best, _ := classification.Best()
+		assert.Equal(t, "work", best.Class)
+	})
+
+	t.Run("rule scores are scaled before merging", func(t *testing.T) {
+		classifier := NewLocalClassifier(
+			KeywordRule{Class: "work", Keywords: []string{"cake"}, Score: 10},
+			KeywordRule{Class: "party", Keywords: []string{"chocolate"}},
+		)
+
+		// Only the rules: the scores are clamped and scaled to sum to 1
+		classification, err := classifier.Classify(ctx, paragraph("chocolate cake"))
+		assert.NoError(t, err)
+		assert.Equal(t, Classification{"work": 0.5, "party": 0.5}, classification)
+
+		// A single rule hit does not overrule a confident model
+		classifier = NewLocalClassifier(KeywordRule{Class: "work", Keywords: []string{"cake"}, Score: 10})
+		assert.NoError(t, classifier.Train(ctx, examples))
+		classification, err = classifier.Classify(ctx, paragraph("chocolate cake recipe with flour and sugar"))
+		assert.NoError(t, err)
+		best, _ := classification.Best()
+		assert.Equal(t, "recipe", best.Class)
+		assert.InDelta(t, 1, classification["recipe"]+classification["work"], 1e-9)
+	})
+
+	t.Run("save and load", func(t *testing.T) {
+		classifier := NewLocalClassifier(KeywordRule{Class: "movie", Keywords: []string{"imdb"}})
+		assert.NoError(t, classifier.Train(ctx, examples))
+
+		path := filepath.Join(t.TempDir(), "classifier.json")
+		assert.NoError(t, classifier.Save(path))
+
+		loaded, err := LoadLocalClassifier(path)
+		assert.NoError(t, err)
+		assert.Equal(t, classifier, loaded)
+
+		expected, _ := classifier.Classify(ctx, paragraph("bread recipe"))
+		actual, err := loaded.Classify(ctx, paragraph("bread recipe"))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := NewLocalClassifier().Classify(ctx, paragraph("anything"))
+		assert.ErrorIs(t, err, ErrClassifierNotTrained)
+
+		assert.ErrorIs(t, NewLocalClassifier().Train(ctx, nil), ErrInvalidTrainingData)
+		assert.ErrorIs(t, NewLocalClassifier().Train(ctx, []TrainingExample{{Block: paragraph("x")}}), ErrInvalidTrainingData)
+
+		_, err = LoadLocalClassifier(filepath.Join(t.TempDir(), "missing.json"))
+		assert.Error(t, err)
+	})
+
+	t.Run("examples from classified blocks", func(t *testing.T) {
+		labeled := paragraph("labeled")
+		labeled.Classification = Classification{"recipe": 0.8, "work": 0.2}
+		uncertain := paragraph("uncertain")
+		uncertain.Classification = Classification{"recipe": 0.4}
+
+		examples := TrainingExamplesFromBlocks([]Block{labeled, uncertain, paragraph("none")}, 0.5)
+		assert.Len(t, examples, 1)
+		assert.Equal(t, "recipe", examples[0].Class)
+		assert.Equal(t, labeled.ID, examples[0].Block.ID)
+	})
+}
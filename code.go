@@ -0,0 +1,51 @@
+package blocks
+
+import (
+	"fmt"
+)
+
+// AddCodeProperties adds the code and its language to the given block
+func AddCodeProperties(b *Block, code *string, language *string) error {
+	if b == nil {
+		return fmt.Errorf("cannot add code properties because given b is nil")
+	}
+
+	if code != nil {
+		if err := b.Properties.ReplaceValue(PropertyKeyTitle, *code); err != nil {
+			return fmt.Errorf("failed to set title property: %w", err)
+		}
+	}
+
+	if language != nil && *language != "" {
+		if err := b.Properties.ReplaceValue(PropertyKeyLanguage, *language); err != nil {
+			return fmt.Errorf("failed to set language property: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// RenderCodeProperties renders a code block as a fenced markdown code block
+func RenderCodeProperties(b Block) string {
+	code, _ := b.Properties.GetString(PropertyKeyTitle)
+	language, _ := b.Properties.GetString(PropertyKeyLanguage)
+
+	return fmt.Sprintf("```%s\n%s\n```", language, code)
+}
+
+// GetCodeProperties returns a list of all code property keys
+func GetCodeProperties() []string {
+	return []string{
+		PropertyKeyTitle,
+		PropertyKeyLanguage,
+	}
+}
+
+func init() {
+	mustRegisterType(TypeDefinition{
+		DataType:     TypeCode,
+		RenderFunc:   renderWith(RenderCodeProperties),
+		PropertyKeys: GetCodeProperties(),
+		Content:      BlockContentTypeTextual,
+	})
+}
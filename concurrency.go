@@ -0,0 +1,286 @@
+package blocks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxUpdateAttempts is the number of times UpdateBlock retries after a concurrent modification
+const MaxUpdateAttempts = 5
+
+// VersionConflictError is returned when a block was modified since the version the caller read
+type VersionConflictError struct {
+	BlockID         uuid.UUID `json:"block_id"`
+	ExpectedVersion int64     `json:"expected_version"`
+	ActualVersion   int64     `json:"actual_version"`
+}
+
+func (e VersionConflictError) Error() string {
+	return fmt.Sprintf("%s: block %s is at version %d, expected %d", ErrVersionConflict, e.BlockID, e.ActualVersion, e.ExpectedVersion)
+}
+
+func (e VersionConflictError) Unwrap() error {
+	return ErrVersionConflict
+}
+
+// MergeConflictError is returned when concurrent edits changed the same fields or property keys differently.
+// Property and style conflicts are listed with paths like "properties.title".
+type MergeConflictError struct {
+	BlockID uuid.UUID `json:"block_id"`
+	Fields  []string  `json:"fields"`
+}
+
+func (e MergeConflictError) Error() string {
+	return fmt.Sprintf("%s: block %s has conflicting changes in %s", ErrMergeConflict, e.BlockID, strings.Join(e.Fields, ", "))
+}
+
+func (e MergeConflictError) Unwrap() error {
+	return ErrMergeConflict
+}
+
+// ETag returns an entity tag identifying the current version of the block, for HTTP If-Match headers
+func (b *Block) ETag() string {
+	return fmt.Sprintf(`"%s-%d"`, b.ID, b.Version)
+}
+
+// MatchETag returns a VersionConflictError if the entity tag does not identify the current version of the block
+func (b *Block) MatchETag(etag string) error {
+	if etag == b.ETag() {
+		return nil
+	}
+
+	expected := int64(-1)
+	if version, ok := parseETagVersion(b.ID, etag); ok {
+		expected = version
+	}
+	return VersionConflictError{BlockID: b.ID, ExpectedVersion: expected, ActualVersion: b.Version}
+}
+
+// parseETagVersion extracts the version from an entity tag created by ETag for the block
+func parseETagVersion(id uuid.UUID, etag string) (int64, bool) {
+	value := strings.Trim(strings.TrimPrefix(etag, "W/"), `"`)
+	versionText, found := strings.CutPrefix(value, id.String()+"-")
+	if !found {
+		return 0, false
+	}
+	version, err := strconv.ParseInt(versionText, 10, 64)
+	return version, err == nil
+}
+
+// CompareAndSwap applies the update to the block if it is still at the expected version.
+// The update runs on a copy; the block is only replaced, with its version increased and UpdatedAt set,
+// if the update succeeds.
+func (b *Block) CompareAndSwap(expectedVersion int64, update func(b *Block) error) error {
+	if b.Version != expectedVersion {
+		return VersionConflictError{BlockID: b.ID, ExpectedVersion: expectedVersion, ActualVersion: b.Version}
+	}
+
+	updated := b.Copy()
+	if err := update(&updated); err != nil {
+		return err
+	}
+	updated.Version = expectedVersion + 1
+	updated.UpdatedAt = time.Now()
+
+	*b = updated
+	return nil
+}
+
+// VersionedStore loads blocks and replaces them atomically if nobody else modified them
+type VersionedStore interface {
+	// Load returns the latest stored version of the block
+	Load(ctx context.Context, id uuid.UUID) (Block, error)
+
+	// CompareAndSwap stores the block if the stored version equals expectedVersion,
+	// and returns a VersionConflictError otherwise
+	CompareAndSwap(ctx context.Context, b Block, expectedVersion int64) error
+}
+
+// InMemoryVersionedStore is a VersionedStore keeping blocks in memory.
+// It is safe for concurrent use.
+type InMemoryVersionedStore struct {
+	mu     sync.Mutex
+	blocks map[uuid.UUID]Block
+}
+
+// NewInMemoryVersionedStore creates a store containing the given blocks
+func NewInMemoryVersionedStore(blocks ...Block) *InMemoryVersionedStore {
+	store := &InMemoryVersionedStore{blocks: make(map[uuid.UUID]Block, len(blocks))}
+	for _, b := range blocks {
+		store.blocks[b.ID] = b.Copy()
+	}
+	return store
+}
+
+func (s *InMemoryVersionedStore) Load(_ context.Context, id uuid.UUID) (Block, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, exists := s.blocks[id]
+	if !exists {
+		return Block{}, fmt.Errorf("%w: %s", ErrBlockNotFound, id)
+	}
+	return b.Copy(), nil
+}
+
+func (s *InMemoryVersionedStore) CompareAndSwap(_ context.Context, b Block, expectedVersion int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, exists := s.blocks[b.ID]
+	switch {
+	case !exists && expectedVersion != 0:
+		return fmt.Errorf("%w: %s", ErrBlockNotFound, b.ID)
+	case exists && stored.Version != expectedVersion:
+		return VersionConflictError{BlockID: b.ID, ExpectedVersion: expectedVersion, ActualVersion: stored.Version}
+	}
+
+	s.blocks[b.ID] = b.Copy()
+	return nil
+}
+
+// UpdateBlock loads the block, applies the update and stores the result with CompareAndSwap.
+// When another writer stored the block in the meantime, the update is merged with theirs using MergeBlocks
+// and stored again, up to MaxUpdateAttempts times. It returns a MergeConflictError if both writers changed
+// the same field or property key, and a VersionConflictError if the block keeps changing.
+func UpdateBlock(ctx context.Context, store VersionedStore, id uuid.UUID, update func(b *Block) error) (Block, error) {
+	base, err := store.Load(ctx, id)
+	if err != nil {
+		return Block{}, err
+	}
+
+	ours := base.Copy()
+	if err := update(&ours); err != nil {
+		return Block{}, err
+	}
+
+	candidate := ours
+	expectedVersion := base.Version
+	for attempt := 1; ; attempt++ {
+		candidate.Version = expectedVersion + 1
+		candidate.UpdatedAt = time.Now()
+
+		err := store.CompareAndSwap(ctx, candidate, expectedVersion)
+		if err == nil {
+			return candidate, nil
+		}
+		if !errors.Is(err, ErrVersionConflict) || attempt == MaxUpdateAttempts {
+			return Block{}, err
+		}
+
+		theirs, err := store.Load(ctx, id)
+		if err != nil {
+			return Block{}, err
+		}
+		candidate, err = MergeBlocks(base, ours, theirs)
+		if err != nil {
+			return Block{}, err
+		}
+		expectedVersion = theirs.Version
+	}
+}
+
+// MergeBlocks merges two concurrent edits of the same base block.
+// A field changed on one side only takes the changed value; properties and styles are merged key by key.
+// Version and UpdatedAt are taken from theirs. If both sides changed the same field or property key to
+// different values, a MergeConflictError lists them.
+func MergeBlocks(base, ours, theirs Block) (Block, error) {
+	merged := theirs.Copy()
+	var conflicts []string
+
+	mergedValue := reflect.ValueOf(&merged).Elem()
+	baseValue := reflect.ValueOf(base)
+	oursValue := reflect.ValueOf(ours.Copy())
+	theirsValue := reflect.ValueOf(theirs)
+	t := baseValue.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		name := getJSONFieldName(t.Field(i))
+		switch name {
+		case BlockPropertyVersion, BlockPropertyUpdatedAt:
+			continue
+		case BlockPropertyProperties:
+			properties, keys := MergeProperties(base.Properties, ours.Properties, theirs.Properties)
+			merged.Properties = properties
+			conflicts = append(conflicts, prefixKeys(BlockPropertyProperties, keys)...)
+		case BlockPropertyStypes:
+			styles, keys := MergeProperties(base.Styles, ours.Styles, theirs.Styles)
+			merged.Styles = styles
+			conflicts = append(conflicts, prefixKeys(BlockPropertyStypes, keys)...)
+		default:
+			baseField, oursField, theirsField := baseValue.Field(i), oursValue.Field(i), theirsValue.Field(i)
+			switch {
+			case equalFieldValues(oursField, baseField), equalFieldValues(oursField, theirsField):
+				// Keep theirs
+			case equalFieldValues(theirsField, baseField):
+				mergedValue.Field(i).Set(oursField)
+			default:
+				conflicts = append(conflicts, name)
+			}
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return Block{}, MergeConflictError{BlockID: theirs.ID, Fields: conflicts}
+	}
+	return merged, nil
+}
+
+// MergeProperties merges two concurrent edits of the same base properties key by key.
+// A key changed, added or removed on one side only takes that change; keys changed differently on both sides
+// keep their value from theirs and are returned as conflicts, sorted.
+func MergeProperties(base, ours, theirs Properties) (Properties, []string) {
+	merged := theirs.Copy()
+	if merged == nil {
+		merged = Properties{}
+	}
+
+	keys := make(map[string]bool)
+	for _, p := range []Properties{base, ours, theirs} {
+		for key := range p {
+			keys[key] = true
+		}
+	}
+
+	var conflicts []string
+	for key := range keys {
+		baseValues, inBase := base[key]
+		oursValues, inOurs := ours[key]
+		theirsValues, inTheirs := theirs[key]
+
+		oursChanged := inOurs != inBase || !reflect.DeepEqual(oursValues, baseValues)
+		theirsChanged := inTheirs != inBase || !reflect.DeepEqual(theirsValues, baseValues)
+		sameResult := inOurs == inTheirs && reflect.DeepEqual(oursValues, theirsValues)
+
+		switch {
+		case !oursChanged || sameResult:
+			// Keep theirs
+		case !theirsChanged && !inOurs:
+			delete(merged, key)
+		case !theirsChanged:
+			merged[key] = copyPropertyValues(oursValues)
+		default:
+			conflicts = append(conflicts, key)
+		}
+	}
+
+	sort.Strings(conflicts)
+	return merged, conflicts
+}
+
+func prefixKeys(prefix string, keys []string) []string {
+	prefixed := make([]string, 0, len(keys))
+	for _, key := range keys {
+		prefixed = append(prefixed, prefix+"."+key)
+	}
+	return prefixed
+}
@@ -0,0 +1,132 @@
+package blocks
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestETag(t *testing.T) {
+	b := NewEmptyBlock()
+	b.Version = 3
+
+	assert.Equal(t, `"`+b.ID.String()+`-3"`, b.ETag())
+	assert.NoError(t, b.MatchETag(b.ETag()))
+
+	err := b.MatchETag(`"` + b.ID.String() + `-2"`)
+	var conflict VersionConflictError
+	assert.True(t, errors.As(err, &conflict))
+	assert.Equal(t, int64(2), conflict.ExpectedVersion)
+	assert.Equal(t, int64(3), conflict.ActualVersion)
+
+	err = b.MatchETag("garbage")
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	assert.True(t, errors.As(err, &conflict))
+	assert.Equal(t, int64(-1), conflict.ExpectedVersion)
+}
+
+func TestBlock_CompareAndSwap(t *testing.T) {
+	b := NewEmptyBlock()
+
+	err := b.CompareAndSwap(0, func(b *Block) error {
+		b.RawBody = "edited"
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), b.Version)
+	assert.Equal(t, "edited", b.RawBody)
+
+	err = b.CompareAndSwap(0, func(b *Block) error {
+		b.RawBody = "stale"
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	assert.Equal(t, "edited", b.RawBody)
+
+	err = b.CompareAndSwap(1, func(b *Block) error {
+		b.RawBody = "failed"
+		return errors.New("boom")
+	})
+	assert.EqualError(t, err, "boom")
+	assert.Equal(t, "edited", b.RawBody)
+	assert.Equal(t, int64(1), b.Version)
+}
+
+func TestUpdateBlock(t *testing.T) {
+	ctx := context.Background()
+
+	newStore := func() (*InMemoryVersionedStore, Block) {
+		b := NewEmptyBlock()
+		b.Properties[PropertyKeyTitle] = []interface{}{"Title"}
+		b.Properties[PropertyKeyDescription] = []interface{}{"Description"}
+		return NewInMemoryVersionedStore(b), b
+	}
+
+	t.Run("stores the update", func(t *testing.T) {
+		store, b := newStore()
+
+		updated, err := UpdateBlock(ctx, store, b.ID, func(b *Block) error {
+			b.RawBody = "body"
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), updated.Version)
+
+		stored, _ := store.Load(ctx, b.ID)
+		assert.Equal(t, "body", stored.RawBody)
+	})
+
+	t.Run("merges non-overlapping concurrent edits", func(t *testing.T) {
+		store, b := newStore()
+
+		updated, err := UpdateBlock(ctx, store, b.ID, func(ours *Block) error {
+			// The enrichment worker stores its change while the user is editing
+			theirs, _ := store.Load(ctx, b.ID)
+			theirs.Properties[PropertyKeyDescription] = []interface{}{"Enriched"}
+			theirs.Meaning = "A note"
+			theirs.Version++
+			assert.NoError(t, store.CompareAndSwap(ctx, theirs, theirs.Version-1))
+
+			ours.Properties[PropertyKeyTitle] = []interface{}{"Edited"}
+			delete(ours.Properties, PropertyKeyDescription)
+			return nil
+		})
+		assert.ErrorIs(t, err, ErrMergeConflict)
+		assert.EqualError(t, err, "merge conflict: block "+b.ID.String()+" has conflicting changes in properties.description")
+
+		updated, err = UpdateBlock(ctx, store, b.ID, func(ours *Block) error {
+			theirs, _ := store.Load(ctx, b.ID)
+			theirs.Properties[PropertyKeyDescription] = []interface{}{"Enriched again"}
+			theirs.Version++
+			assert.NoError(t, store.CompareAndSwap(ctx, theirs, theirs.Version-1))
+
+			ours.Properties[PropertyKeyTitle] = []interface{}{"Edited"}
+			ours.RawBody = "body"
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), updated.Version)
+		assert.Equal(t, "Edited", updated.Properties[PropertyKeyTitle][0])
+		assert.Equal(t, "Enriched again", updated.Properties[PropertyKeyDescription][0])
+		assert.Equal(t, "body", updated.RawBody)
+	})
+
+	t.Run("stale write is rejected by the store", func(t *testing.T) {
+		store, b := newStore()
+
+		err := store.CompareAndSwap(ctx, b, 7)
+		assert.ErrorIs(t, err, ErrVersionConflict)
+	})
+}
+
+func TestMergeProperties(t *testing.T) {
+	base := Properties{"a": {"1"}, "b": {"1"}, "c": {"1"}, "d": {"1"}}
+	ours := Properties{"a": {"2"}, "b": {"1"}, "d": {"ours"}, "e": {"new"}}
+	theirs := Properties{"a": {"1"}, "b": {"3"}, "c": {"1"}, "d": {"theirs"}}
+
+	merged, conflicts := MergeProperties(base, ours, theirs)
+	assert.Equal(t, Properties{"a": {"2"}, "b": {"3"}, "d": {"theirs"}, "e": {"new"}}, merged)
+	assert.Equal(t, []string{"d"}, conflicts)
+}
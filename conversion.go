@@ -0,0 +1,245 @@
+package blocks
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ConversionRule declares how the properties of one block type are migrated to another type.
+// Properties are kept under the same key, or under the key given in Rename, if the target type supports them,
+// and dropped otherwise. Migrate can derive further properties and Defaults fill keys that are still missing.
+type ConversionRule struct {
+	From DataType
+	To   DataType
+
+	// Rename maps source property keys to target property keys
+	Rename map[string]string
+	// Defaults are set on the converted block when the key is missing
+	Defaults map[string]interface{}
+	// Migrate derives properties of the target from the source block; returning an error refuses the conversion
+	Migrate func(source Block, target *Block) error
+}
+
+// ConversionReport describes what a conversion did to the properties
+type ConversionReport struct {
+	From DataType `json:"from"`
+	To   DataType `json:"to"`
+
+	// Kept lists the target keys that were copied from the source, sorted
+	Kept []string `json:"kept"`
+	// Renamed maps the source keys that were renamed to their target key
+	Renamed map[string]string `json:"renamed,omitempty"`
+	// Added lists the keys set by Migrate or Defaults, sorted
+	Added []string `json:"added"`
+	// Dropped lists the source keys the target type does not support, or whose target key was already taken by
+	// another source key, sorted
+	Dropped []string `json:"dropped"`
+	// Problems lists what the converted block still misses to be valid for the target type
+	Problems []ValidationError `json:"problems,omitempty"`
+}
+
+type conversionKey struct {
+	from DataType
+	to   DataType
+}
+
+var (
+	conversionsMu sync.RWMutex
+	conversions   = make(map[conversionKey]ConversionRule)
+)
+
+// RegisterConversion declares the rule used by ConvertType for a pair of types, replacing any previous rule
+func RegisterConversion(rule ConversionRule) {
+	conversionsMu.Lock()
+	defer conversionsMu.Unlock()
+	conversions[conversionKey{rule.From, rule.To}] = rule
+}
+
+// LookupConversion returns the rule declared for a pair of types
+func LookupConversion(from, to DataType) (ConversionRule, bool) {
+	conversionsMu.RLock()
+	defer conversionsMu.RUnlock()
+	rule, exists := conversions[conversionKey{from, to}]
+	return rule, exists
+}
+
+// ConvertType changes the type of the block and migrates its properties using the rule declared for the
+// pair of types. Without a declared rule, properties supported by the new type are kept and the others dropped.
+// Converting a final type is refused if any property would be dropped. The block is only modified on success.
+func ConvertType(b *Block, to DataType) (ConversionReport, error) {
+	report := ConversionReport{From: b.Type, To: to, Kept: []string{}, Added: []string{}, Dropped: []string{}}
+
+	target, ok := LookupType(to)
+	if !ok {
+		return report, fmt.Errorf("%w: %s", ErrUnknownType, to)
+	}
+	if b.Type == to {
+		for key := range b.Properties {
+			report.Kept = append(report.Kept, key)
+		}
+		sort.Strings(report.Kept)
+		return report, nil
+	}
+
+	rule, _ := LookupConversion(b.Type, to)
+	supported := targetSupportsKey(target)
+
+	converted := b.Copy()
+	converted.Type = to
+	source := converted.Properties
+	converted.Properties = Properties{}
+
+	// Several source keys can map to the same target key. A key kept under its own name wins over
+	// keys renamed to it, otherwise the first source key in sorted order wins; the others are dropped.
+	sourceKeys := make([]string, 0, len(source))
+	for key := range source {
+		sourceKeys = append(sourceKeys, key)
+	}
+	isRenamed := func(key string) bool {
+		renamed, ok := rule.Rename[key]
+		return ok && renamed != key
+	}
+	sort.Slice(sourceKeys, func(i, j int) bool {
+		if isRenamed(sourceKeys[i]) != isRenamed(sourceKeys[j]) {
+			return !isRenamed(sourceKeys[i])
+		}
+		return sourceKeys[i] < sourceKeys[j]
+	})
+
+	for _, key := range sourceKeys {
+		targetKey := key
+		if renamed, ok := rule.Rename[key]; ok {
+			targetKey = renamed
+		}
+		if _, taken := converted.Properties[targetKey]; taken || !supported(targetKey) {
+			report.Dropped = append(report.Dropped, key)
+			continue
+		}
+		converted.Properties[targetKey] = source[key]
+		report.Kept = append(report.Kept, targetKey)
+		if targetKey != key {
+			if report.Renamed == nil {
+				report.Renamed = make(map[string]string)
+			}
+			report.Renamed[key] = targetKey
+		}
+	}
+
+	if b.Type.IsFinal() && len(report.Dropped) > 0 {
+		sort.Strings(report.Dropped)
+		return report, fmt.Errorf("%w: %s to %s drops %s", ErrConversionDataLoss, b.Type, to, strings.Join(report.Dropped, ", "))
+	}
+
+	before := make(map[string]bool, len(converted.Properties))
+	for key := range converted.Properties {
+		before[key] = true
+	}
+
+	if rule.Migrate != nil {
+		if err := rule.Migrate(b.Copy(), &converted); err != nil {
+			return report, fmt.Errorf("%w: %s to %s: %w", ErrConversionNotAllowed, b.Type, to, err)
+		}
+	}
+	for key, value := range rule.Defaults {
+		if _, exists := converted.Properties[key]; !exists {
+			if err := converted.Properties.ReplaceValue(key, value); err != nil {
+				return report, fmt.Errorf("failed to set default %s: %w", key, err)
+			}
+		}
+	}
+
+	for key := range converted.Properties {
+		if !before[key] {
+			report.Added = append(report.Added, key)
+		}
+	}
+
+	sort.Strings(report.Kept)
+	sort.Strings(report.Added)
+	sort.Strings(report.Dropped)
+	report.Problems = Validate(converted)
+
+	*b = converted
+	return report, nil
+}
+
+// targetSupportsKey returns a function reporting whether the type accepts the property key
+func targetSupportsKey(handler TypeHandler) func(key string) bool {
+	if provider, ok := handler.(SchemaProvider); ok {
+		if schema := provider.PropertySchema(); schema != nil {
+			return func(key string) bool {
+				_, exists := schema.Spec(key)
+				return exists || schema.AllowUnknown
+			}
+		}
+	}
+
+	keys := make(map[string]bool)
+	for _, key := range handler.Properties() {
+		keys[key] = true
+	}
+	return func(key string) bool {
+		return keys[key]
+	}
+}
+
+var youtubeVideoIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+
+// ExtractYoutubeVideoID returns the video ID of a youtube.com or youtu.be URL
+func ExtractYoutubeVideoID(rawURL string) (string, bool) {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", false
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+	host = strings.TrimPrefix(host, "m.")
+	segments := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+
+	var id string
+	switch host {
+	case "youtu.be":
+		id = segments[0]
+	case "youtube.com", "music.youtube.com", "youtube-nocookie.com":
+		switch {
+		case segments[0] == "watch":
+			id = parsed.Query().Get("v")
+		case len(segments) >= 2 && (segments[0] == "shorts" || segments[0] == "embed" || segments[0] == "live" || segments[0] == "v"):
+			id = segments[1]
+		}
+	}
+
+	if !youtubeVideoIDPattern.MatchString(id) {
+		return "", false
+	}
+	return id, true
+}
+
+func init() {
+	// Text blocks become unchecked to-dos
+	for _, from := range []DataType{TypeParagraph, TypeHeader1, TypeHeader2, TypeHeader3, TypeHeader4, TypeHeader5,
+		TypeHeader6, TypeBulletListItem, TypeNumberedListItem, TypeQuote, TypeFragment} {
+		RegisterConversion(ConversionRule{
+			From:     from,
+			To:       TypeToDo,
+			Defaults: map[string]interface{}{PropertyKeyChecked: false},
+		})
+	}
+
+	RegisterConversion(ConversionRule{
+		From: TypeLink,
+		To:   TypeYouTube,
+		Migrate: func(source Block, target *Block) error {
+			link, _ := source.Properties.GetString(PropertyKeyURL)
+			videoID, ok := ExtractYoutubeVideoID(link)
+			if !ok {
+				return fmt.Errorf("%q is not a YouTube video URL", link)
+			}
+			return target.Properties.ReplaceValue(PropertyKeyVideoID, videoID)
+		},
+	})
+}
@@ -0,0 +1,174 @@
+package blocks
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertType(t *testing.T) {
+	t.Run("paragraph to to-do", func(t *testing.T) {
+		b := NewEmptyBlock()
+		b.Type = TypeParagraph
+		b.Properties[PropertyKeyTitle] = []interface{}{"Buy milk"}
+
+		report, err := ConvertType(&b, TypeToDo)
+		assert.NoError(t, err)
+		assert.Equal(t, TypeToDo, b.Type)
+		assert.Equal(t, []string{PropertyKeyTitle}, report.Kept)
+		assert.Equal(t, []string{PropertyKeyChecked}, report.Added)
+		assert.Empty(t, report.Dropped)
+		assert.Empty(t, report.Problems)
+
+		title, _ := b.Properties.GetString(PropertyKeyTitle)
+		assert.Equal(t, "Buy milk", title)
+		checked, _ := b.Properties.GetBool(PropertyKeyChecked)
+		assert.False(t, checked)
+	})
+
+	t.Run("link to youtube extracts video id", func(t *testing.T) {
+		b := NewEmptyBlock()
+		b.Type = TypeLink
+		b.Properties[PropertyKeyTitle] = []interface{}{"Never Gonna Give You Up"}
+		b.Properties[PropertyKeyURL] = []interface{}{"https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=42"}
+
+		report, err := ConvertType(&b, TypeYouTube)
+		assert.NoError(t, err)
+		assert.Equal(t, TypeYouTube, b.Type)
+		assert.Equal(t, []string{PropertyKeyTitle, PropertyKeyURL}, report.Kept)
+		assert.Equal(t, []string{PropertyKeyVideoID}, report.Added)
+
+		videoID, _ := b.Properties.GetString(PropertyKeyVideoID)
+		assert.Equal(t, "dQw4w9WgXcQ", videoID)
+	})
+
+	t.Run("link to youtube refuses other urls", func(t *testing.T) {
+		b := NewEmptyBlock()
+		b.Type = TypeLink
+		b.Properties[PropertyKeyURL] = []interface{}{"https://example.com"}
+
+		_, err := ConvertType(&b, TypeYouTube)
+		assert.ErrorIs(t, err, ErrConversionNotAllowed)
+		assert.Equal(t, TypeLink, b.Type)
+		assert.NotContains(t, b.Properties, PropertyKeyVideoID)
+	})
+
+	t.Run("unsupported keys are dropped", func(t *testing.T) {
+		b := NewEmptyBlock()
+		b.Type = TypeToDo
+		b.Properties[PropertyKeyTitle] = []interface{}{"Call mum"}
+		b.Properties[PropertyKeyChecked] = []interface{}{true}
+
+		report, err := ConvertType(&b, TypeParagraph)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{PropertyKeyChecked}, report.Dropped)
+		assert.NotContains(t, b.Properties, PropertyKeyChecked)
+	})
+
+	t.Run("final types refuse to lose data", func(t *testing.T) {
+		b := NewEmptyBlock()
+		b.Type = TypeMovie
+		b.Properties[PropertyKeyTitle] = []interface{}{"The Matrix"}
+		b.Properties[PropertyKeyReleaseYear] = []interface{}{1999}
+
+		report, err := ConvertType(&b, TypeParagraph)
+		assert.ErrorIs(t, err, ErrConversionDataLoss)
+		assert.Contains(t, report.Dropped, PropertyKeyReleaseYear)
+		assert.Equal(t, TypeMovie, b.Type)
+		assert.Contains(t, b.Properties, PropertyKeyReleaseYear)
+	})
+
+	t.Run("unknown target type", func(t *testing.T) {
+		b := NewEmptyBlock()
+		_, err := ConvertType(&b, DataType("unknown"))
+		assert.ErrorIs(t, err, ErrUnknownType)
+	})
+
+	t.Run("custom rule renames keys", func(t *testing.T) {
+		RegisterConversion(ConversionRule{
+			From:   TypeCode,
+			To:     TypeQuote,
+			Rename: map[string]string{PropertyKeyLanguage: PropertyKeyTitle},
+		})
+		defer func() {
+			conversionsMu.Lock()
+			delete(conversions, conversionKey{TypeCode, TypeQuote})
+			conversionsMu.Unlock()
+		}()
+
+		b := NewEmptyBlock()
+		b.Type = TypeCode
+		b.Properties[PropertyKeyLanguage] = []interface{}{"go"}
+
+		report, err := ConvertType(&b, TypeQuote)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{PropertyKeyLanguage: PropertyKeyTitle}, report.Renamed)
+		title, _ := b.Properties.GetString(PropertyKeyTitle)
+		assert.Equal(t, "go", title)
+	})
+
+	t.Run("colliding keys are dropped", func(t *testing.T) {
+		RegisterConversion(ConversionRule{
+			From:   TypeCode,
+			To:     TypeQuote,
+			Rename: map[string]string{PropertyKeyLanguage: PropertyKeyTitle, PropertyKeyDescription: PropertyKeyTitle},
+		})
+		defer func() {
+			conversionsMu.Lock()
+			delete(conversions, conversionKey{TypeCode, TypeQuote})
+			conversionsMu.Unlock()
+		}()
+
+		// The key kept under its own name wins
+		b := NewEmptyBlock()
+		b.Type = TypeCode
+		b.Properties[PropertyKeyTitle] = []interface{}{"fmt.Println()"}
+		b.Properties[PropertyKeyLanguage] = []interface{}{"go"}
+		b.Properties[PropertyKeyDescription] = []interface{}{"Printing"}
+
+		report, err := ConvertType(&b, TypeQuote)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{PropertyKeyTitle}, report.Kept)
+		assert.Equal(t, []string{PropertyKeyDescription, PropertyKeyLanguage}, report.Dropped)
+		assert.Nil(t, report.Renamed)
+		title, _ := b.Properties.GetString(PropertyKeyTitle)
+		assert.Equal(t, "fmt.Println()", title)
+
+		// Between renamed keys the first in sorted order wins
+		b = NewEmptyBlock()
+		b.Type = TypeCode
+		b.Properties[PropertyKeyLanguage] = []interface{}{"go"}
+		b.Properties[PropertyKeyDescription] = []interface{}{"Printing"}
+
+		report, err = ConvertType(&b, TypeQuote)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{PropertyKeyTitle}, report.Kept)
+		assert.Equal(t, []string{PropertyKeyLanguage}, report.Dropped)
+		assert.Equal(t, map[string]string{PropertyKeyDescription: PropertyKeyTitle}, report.Renamed)
+		title, _ = b.Properties.GetString(PropertyKeyTitle)
+		assert.Equal(t, "Printing", title)
+	})
+}
+
+func TestExtractYoutubeVideoID(t *testing.T) {
+	tests := []struct {
+		url string
+		id  string
+		ok  bool
+	}{
+		{"https://www.youtube.com/watch?v=dQw4w9WgXcQ", "dQw4w9WgXcQ", true},
+		{"https://youtu.be/dQw4w9WgXcQ?si=abc", "dQw4w9WgXcQ", true},
+		{"https://m.youtube.com/shorts/dQw4w9WgXcQ", "dQw4w9WgXcQ", true},
+		{"https://www.youtube-nocookie.com/embed/dQw4w9WgXcQ", "dQw4w9WgXcQ", true},
+		{"https://www.youtube.com/channel/UC123", "", false},
+		{"https://example.com/watch?v=dQw4w9WgXcQ", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			id, ok := ExtractYoutubeVideoID(tt.url)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.id, id)
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package blocks
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SubtreeDuplicate is the result of DuplicateSubtree
+type SubtreeDuplicate struct {
+	// Blocks contains the new blocks, starting with the duplicated root, in document order
+	Blocks []Block
+	// Changed contains the existing ancestors updated to include the duplicate
+	Changed []Block
+	// IDs maps every original ID to the ID of its duplicate
+	IDs map[uuid.UUID]uuid.UUID
+}
+
+// Root returns the duplicate of the block passed to DuplicateSubtree
+func (d SubtreeDuplicate) Root() Block {
+	return d.Blocks[0]
+}
+
+// DuplicateSubtree deep copies a block and all its descendants with fresh IDs.
+// Content, ParentID, RootParentID and ChildrenRecursive are rewired to the new IDs, and [[block:uuid]]
+// annotations in properties, styles, RawBody and Meaning that point within the subtree are rewritten.
+// If the block has a parent in the tree, the duplicate is inserted right after it and the ancestors'
+// ChildrenRecursive are extended. The tree is updated with all new and changed blocks.
+func DuplicateSubtree(tree *BlockTree, blockID uuid.UUID) (SubtreeDuplicate, error) {
+	original, exists := tree.Get(blockID)
+	if !exists {
+		return SubtreeDuplicate{}, fmt.Errorf("%w: %s", ErrBlockNotFound, blockID)
+	}
+
+	subtree := append([]uuid.UUID{blockID}, tree.descendantIDs(blockID)...)
+	ids := make(map[uuid.UUID]uuid.UUID, len(subtree))
+	for _, id := range subtree {
+		ids[id] = uuid.New()
+	}
+
+	// Descendants hang below the duplicated root, or below the original root if there is one
+	rootID := ids[blockID]
+	if original.RootParentID != nil {
+		rootID = *original.RootParentID
+	}
+
+	now := time.Now()
+	duplicate := SubtreeDuplicate{
+		Blocks: make([]Block, 0, len(subtree)),
+		IDs:    ids,
+	}
+	for _, id := range subtree {
+		b, _ := tree.Get(id)
+		c := b.Copy()
+
+		c.ID = ids[id]
+		c.CreatedAt = now
+		c.UpdatedAt = now
+		c.Version = 0
+		c.Content = remapIDs(c.Content, ids)
+		c.ChildrenRecursive = remapIDs(c.ChildrenRecursive, ids)
+		if id == blockID {
+			c.RootParentID = copyIDPointer(original.RootParentID)
+		} else {
+			c.ParentID = remapIDPointer(c.ParentID, ids)
+			c.RootParentID = copyIDPointer(&rootID)
+		}
+
+		rewritePropertyReferences(c.Properties, ids)
+		rewritePropertyReferences(c.Styles, ids)
+		c.RawBody = RewriteReferences(c.RawBody, ids)
+		c.Meaning = RewriteReferences(c.Meaning, ids)
+
+		duplicate.Blocks = append(duplicate.Blocks, c)
+	}
+
+	if parent, ok := tree.Parent(blockID); ok {
+		for _, ancestorID := range append([]uuid.UUID{parent.ID}, tree.ancestorIDs(parent.ID)...) {
+			a, _ := tree.Get(ancestorID)
+			ancestor := a.Copy()
+			ancestor.UpdatedAt = now
+
+			if ancestorID == parent.ID {
+				if err := ancestor.InsertChild(ids[blockID], blockID); err != nil {
+					ancestor.AppendChild(ids[blockID])
+				}
+			}
+			for _, id := range subtree {
+				ancestor.ChildrenRecursive = append(ancestor.ChildrenRecursive, ids[id])
+			}
+
+			duplicate.Changed = append(duplicate.Changed, ancestor)
+		}
+	}
+
+	tree.putAll(append(append([]Block{}, duplicate.Changed...), duplicate.Blocks...))
+
+	return duplicate, nil
+}
+
+// RewriteReferences replaces the [[block:uuid]] annotations whose ID is in the map with the mapped ID.
+// Other annotations are left untouched.
+func RewriteReferences(text string, ids map[uuid.UUID]uuid.UUID) string {
+	if text == "" || len(ids) == 0 {
+		return text
+	}
+	return referencePattern.ReplaceAllStringFunc(text, func(annotation string) string {
+		match := referencePattern.FindStringSubmatch(annotation)
+		id, err := uuid.Parse(match[1])
+		if err != nil {
+			return annotation
+		}
+		if newID, ok := ids[id]; ok {
+			return fmt.Sprintf("[[block:%s]]", newID)
+		}
+		return annotation
+	})
+}
+
+// rewritePropertyReferences rewrites the references in every text value of the properties in place
+func rewritePropertyReferences(p Properties, ids map[uuid.UUID]uuid.UUID) {
+	for key, values := range p {
+		for i, value := range values {
+			p[key][i] = rewriteValueReferences(value, ids)
+		}
+	}
+}
+
+func rewriteValueReferences(value interface{}, ids map[uuid.UUID]uuid.UUID) interface{} {
+	switch v := value.(type) {
+	case string:
+		return RewriteReferences(v, ids)
+	case []string:
+		for i := range v {
+			v[i] = RewriteReferences(v[i], ids)
+		}
+		return v
+	case []interface{}:
+		for i := range v {
+			v[i] = rewriteValueReferences(v[i], ids)
+		}
+		return v
+	case map[string]interface{}:
+		for key := range v {
+			v[key] = rewriteValueReferences(v[key], ids)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// remapIDs maps the IDs through the map, keeping IDs that are not in it
+func remapIDs(list []uuid.UUID, ids map[uuid.UUID]uuid.UUID) []uuid.UUID {
+	if list == nil {
+		return nil
+	}
+	remapped := make([]uuid.UUID, len(list))
+	for i, id := range list {
+		if newID, ok := ids[id]; ok {
+			id = newID
+		}
+		remapped[i] = id
+	}
+	return remapped
+}
+
+func remapIDPointer(id *uuid.UUID, ids map[uuid.UUID]uuid.UUID) *uuid.UUID {
+	if id == nil {
+		return nil
+	}
+	if newID, ok := ids[*id]; ok {
+		return &newID
+	}
+	return copyIDPointer(id)
+}
@@ -0,0 +1,101 @@
+package blocks
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDuplicateSubtree(t *testing.T) {
+	newTree := func(t *testing.T) (*BlockTree, Block, Block, Block, Block, Block, uuid.UUID) {
+		page, a, a1, a2, b := newTestTreeBlocks()
+		external := uuid.New()
+
+		a.Properties[PropertyKeyTitle] = []interface{}{"see " + a1.AnnotationID() + " and [[block:" + external.String() + "]]"}
+		a.Properties[PropertyKeyGenres] = []interface{}{[]string{a2.AnnotationID()}}
+		a1.RawBody = "<p>" + a2.AnnotationID() + "</p>"
+
+		tree, err := NewBlockTree([]Block{page, a, a1, a2, b})
+		assert.NoError(t, err)
+		return tree, page, a, a1, a2, b, external
+	}
+
+	t.Run("duplicate nested block", func(t *testing.T) {
+		tree, page, a, a1, a2, b, external := newTree(t)
+
+		duplicate, err := DuplicateSubtree(tree, a.ID)
+		assert.NoError(t, err)
+		assert.Len(t, duplicate.Blocks, 3)
+		assert.Equal(t, []uuid.UUID{page.ID}, blockIDs(duplicate.Changed))
+
+		newA, newA1, newA2 := duplicate.IDs[a.ID], duplicate.IDs[a1.ID], duplicate.IDs[a2.ID]
+		assert.Equal(t, []uuid.UUID{newA, newA1, newA2}, blockIDs(duplicate.Blocks))
+
+		root := duplicate.Root()
+		assert.Equal(t, page.ID, *root.ParentID)
+		assert.Equal(t, page.ID, *root.RootParentID)
+		assert.Equal(t, []uuid.UUID{newA1, newA2}, root.Content)
+		assert.Equal(t, []uuid.UUID{newA1, newA2}, root.ChildrenRecursive)
+		assert.Equal(t, "see [[block:"+newA1.String()+"]] and [[block:"+external.String()+"]]", root.Properties[PropertyKeyTitle][0])
+		assert.Equal(t, []string{"[[block:" + newA2.String() + "]]"}, root.Properties[PropertyKeyGenres][0])
+		assert.Equal(t, "<p>[[block:"+newA2.String()+"]]</p>", duplicate.Blocks[1].RawBody)
+		assert.Equal(t, newA, *duplicate.Blocks[1].ParentID)
+		assert.Equal(t, page.ID, *duplicate.Blocks[1].RootParentID)
+
+		// The original is untouched
+		original, _ := tree.Get(a.ID)
+		assert.Equal(t, a.Properties[PropertyKeyTitle], original.Properties[PropertyKeyTitle])
+		assert.Equal(t, []string{a2.AnnotationID()}, original.Properties[PropertyKeyGenres][0])
+
+		assert.Equal(t, []uuid.UUID{a.ID, newA, b.ID}, blockIDs(tree.Children(page.ID)))
+		report, err := CheckIntegrity(tree.Blocks())
+		assert.NoError(t, err)
+		assert.False(t, report.HasIssues(), "%v", report.Issues)
+	})
+
+	t.Run("duplicate page", func(t *testing.T) {
+		tree, page, _, _, _, _, _ := newTree(t)
+
+		duplicate, err := DuplicateSubtree(tree, page.ID)
+		assert.NoError(t, err)
+		assert.Len(t, duplicate.Blocks, 5)
+		assert.Empty(t, duplicate.Changed)
+
+		newPage := duplicate.IDs[page.ID]
+		assert.Nil(t, duplicate.Root().ParentID)
+		assert.Nil(t, duplicate.Root().RootParentID)
+		for _, b := range duplicate.Blocks[1:] {
+			assert.Equal(t, newPage, *b.RootParentID)
+		}
+
+		assert.Len(t, tree.Roots(), 2)
+		report, err := CheckIntegrity(tree.Blocks())
+		assert.NoError(t, err)
+		assert.False(t, report.HasIssues(), "%v", report.Issues)
+	})
+
+	t.Run("unknown block", func(t *testing.T) {
+		tree, _, _, _, _, _, _ := newTree(t)
+
+		_, err := DuplicateSubtree(tree, uuid.New())
+		assert.ErrorIs(t, err, ErrBlockNotFound)
+	})
+}
+
+func TestBlock_CloneIsDeep(t *testing.T) {
+	b := NewEmptyBlock()
+	b.Properties[PropertyKeyGenres] = []interface{}{[]string{"Drama"}}
+	b.Content = []uuid.UUID{uuid.New()}
+	b.Classification = Classification{"movie": 0.9}
+
+	clone := b.Clone()
+	clone.Properties[PropertyKeyGenres][0].([]string)[0] = "Comedy"
+	clone.Content[0] = uuid.Nil
+	clone.Classification["movie"] = 0.1
+
+	assert.NotEqual(t, b.ID, clone.ID)
+	assert.Equal(t, []string{"Drama"}, b.Properties[PropertyKeyGenres][0])
+	assert.NotEqual(t, uuid.Nil, b.Content[0])
+	assert.Equal(t, 0.9, b.Classification["movie"])
+}
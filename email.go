@@ -331,3 +331,13 @@ func GetEmailProperties() []string {
 		PropertyKeyLabels,
 	}
 }
+
+func init() {
+	mustRegisterType(TypeDefinition{
+		DataType:     TypeEmail,
+		RenderFunc:   renderWith(RenderEmailProperties),
+		PropertyKeys: GetEmailProperties(),
+		Content:      BlockContentTypeStructural,
+		Schema:       NewPropertySchema(GetEmailProperties()...).Require(PropertyKeyEmailID, PropertyKeyFrom),
+	})
+}
@@ -3,3 +3,31 @@ package blocks
 import "errors"
 
 var ErrUnauthorizedBlockAccess = errors.New("unauthorized block access")
+
+var ErrInvalidTypeHandler = errors.New("invalid type handler")
+var ErrTypeAlreadyRegistered = errors.New("type already registered")
+
+var ErrBlockNotFound = errors.New("block not found")
+var ErrDuplicateBlockID = errors.New("duplicate block id")
+var ErrInconsistentTree = errors.New("inconsistent block tree")
+var ErrInvalidMove = errors.New("invalid move")
+
+var ErrFieldNotWritable = errors.New("field not writable")
+
+var ErrRevisionNotFound = errors.New("revision not found")
+var ErrRevisionOutOfOrder = errors.New("revision version is not greater than the latest version")
+
+var ErrVersionConflict = errors.New("version conflict")
+var ErrMergeConflict = errors.New("merge conflict")
+
+var ErrUnknownLifecycleStatus = errors.New("unknown lifecycle status")
+var ErrIllegalTransition = errors.New("illegal lifecycle transition")
+
+var ErrUnknownType = errors.New("unknown block type")
+var ErrConversionNotAllowed = errors.New("type conversion not allowed")
+var ErrConversionDataLoss = errors.New("type conversion would lose data")
+
+var ErrInvalidTaxonomy = errors.New("invalid taxonomy")
+
+var ErrClassifierNotTrained = errors.New("classifier not trained")
+var ErrInvalidTrainingData = errors.New("invalid training data")
@@ -0,0 +1,246 @@
+package blocks
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FailureStage is the processing stage in which a block failed
+type FailureStage string
+
+const (
+	FailureStagePreProcessing  FailureStage = "pre_processing"
+	FailureStageEnrichment     FailureStage = "enrichment"
+	FailureStageRouting        FailureStage = "routing"
+	FailureStageProcessing     FailureStage = "processing"
+	FailureStagePostProcessing FailureStage = "post_processing"
+)
+
+var failureStageStatuses = map[FailureStage]struct {
+	failed LifecycleStatus
+	retry  LifecycleStatus
+}{
+	FailureStagePreProcessing:  {LifecycleStatusPreProcessingFailed, LifecycleStatusPreProcessing},
+	FailureStageEnrichment:     {LifecycleStatusEnrichmentFailed, LifecycleStatusEnriching},
+	FailureStageRouting:        {LifecycleStatusRoutingFailed, LifecycleStatusTransformed},
+	FailureStageProcessing:     {LifecycleStatusProcessingFailed, LifecycleStatusProcessing},
+	FailureStagePostProcessing: {LifecycleStatusPostProcessingFailed, LifecycleStatusPostProcessing},
+}
+
+func (s FailureStage) String() string {
+	return string(s)
+}
+
+// FailedStatus returns the lifecycle status of blocks that failed in this stage
+func (s FailureStage) FailedStatus() LifecycleStatus {
+	return failureStageStatuses[s].failed
+}
+
+// RetryStatus returns the lifecycle status a block moves to when this stage is retried
+func (s FailureStage) RetryStatus() LifecycleStatus {
+	return failureStageStatuses[s].retry
+}
+
+// FailureStageOf returns the stage of a failed lifecycle status
+func FailureStageOf(status LifecycleStatus) (FailureStage, bool) {
+	for stage, statuses := range failureStageStatuses {
+		if statuses.failed == status {
+			return stage, true
+		}
+	}
+	return "", false
+}
+
+// Failure describes a single failed attempt
+type Failure struct {
+	Stage   FailureStage
+	Code    string
+	Message string
+	// NonRetryable marks failures that will not go away by retrying, such as invalid input
+	NonRetryable bool
+	At           time.Time
+}
+
+// RecordFailure stores the failure in LastError.
+// Attempts are counted per stage: a failure in another stage than the previous one starts counting again.
+// The next retry time is cleared and left to the retry planner.
+func (b *Block) RecordFailure(f Failure) {
+	at := f.At
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	attempts := 1
+	firstFailedAt := at
+	if b.LastError.Stage == f.Stage && b.LastError.Attempts > 0 {
+		attempts = b.LastError.Attempts + 1
+		if b.LastError.FirstFailedAt != nil {
+			firstFailedAt = *b.LastError.FirstFailedAt
+		}
+	}
+
+	b.LastError = LastError{
+		Message:       f.Message,
+		Stage:         f.Stage,
+		Code:          f.Code,
+		NonRetryable:  f.NonRetryable,
+		Attempts:      attempts,
+		FirstFailedAt: &firstFailedAt,
+		LastFailedAt:  &at,
+	}
+}
+
+// ClearFailure resets LastError after the block was processed successfully
+func (b *Block) ClearFailure() {
+	b.LastError = LastError{}
+}
+
+// BackoffPolicy defines how long to wait between retries and when to give up
+type BackoffPolicy struct {
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	Multiplier   float64
+
+	// MaxAttempts is the number of failed attempts after which a block is put on hold; 0 means unlimited
+	MaxAttempts int
+}
+
+// DefaultBackoffPolicy waits 1 minute after the first failure, doubling up to 6 hours, and gives up after 5 attempts
+func DefaultBackoffPolicy() BackoffPolicy {
+	return BackoffPolicy{
+		InitialDelay: time.Minute,
+		MaxDelay:     6 * time.Hour,
+		Multiplier:   2,
+		MaxAttempts:  5,
+	}
+}
+
+// Delay returns the time to wait after the given number of failed attempts
+func (p BackoffPolicy) Delay(attempts int) time.Duration {
+	if attempts < 1 {
+		return 0
+	}
+
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	delay := float64(p.InitialDelay) * math.Pow(multiplier, float64(attempts-1))
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		return p.MaxDelay
+	}
+	return time.Duration(delay)
+}
+
+// RetryAction is what the retry planner decided for a failed block
+type RetryAction string
+
+const (
+	RetryActionRetryNow RetryAction = "retry_now"
+	RetryActionWait     RetryAction = "wait"
+	RetryActionOnHold   RetryAction = "on_hold"
+)
+
+// RetryDecision is the plan for a single failed block
+type RetryDecision struct {
+	BlockID  uuid.UUID    `json:"block_id"`
+	Stage    FailureStage `json:"stage"`
+	Action   RetryAction  `json:"action"`
+	Attempts int          `json:"attempts"`
+
+	// Status is the lifecycle status to move the block to: the retry status of the stage, or on_hold
+	Status      LifecycleStatus `json:"status,omitempty"`
+	NextRetryAt time.Time       `json:"next_retry_at"`
+	Reason      string          `json:"reason"`
+}
+
+// RetryPlan groups the decisions for a set of failed blocks, each group sorted by next retry time
+type RetryPlan struct {
+	RetryNow []RetryDecision `json:"retry_now"`
+	Wait     []RetryDecision `json:"wait"`
+	OnHold   []RetryDecision `json:"on_hold"`
+}
+
+// PlanRetries decides for every block in a failed status whether it should be retried now, wait for its next
+// retry time, or be put on hold because the failure is not retryable or it failed too often.
+// The next retry time is NextRetryAt when set, otherwise the last failure time plus the backoff delay.
+// The stage is taken from the failed status; LastError is only used when it was recorded for that stage or
+// has no stage. Blocks that are not in a failed status are ignored.
+func PlanRetries(blocks []Block, policy BackoffPolicy, now time.Time) RetryPlan {
+	var plan RetryPlan
+
+	for _, b := range blocks {
+		statusStage, failed := FailureStageOf(b.LifecycleStatus)
+		if !failed {
+			continue
+		}
+
+		// The status decides the stage; a LastError left over from another stage says nothing about this failure
+		stage := statusStage
+		lastError := b.LastError
+		if lastError.Stage != "" && lastError.Stage != stage {
+			lastError = LastError{}
+		}
+		attempts := lastError.Attempts
+		if attempts < 1 {
+			attempts = 1
+		}
+
+		decision := RetryDecision{BlockID: b.ID, Stage: stage, Attempts: attempts}
+
+		switch {
+		case lastError.NonRetryable:
+			decision.Action = RetryActionOnHold
+			decision.Status = LifecycleStatusOnHold
+			decision.Reason = describeFailure(lastError, "is not retryable")
+			plan.OnHold = append(plan.OnHold, decision)
+			continue
+		case policy.MaxAttempts > 0 && attempts >= policy.MaxAttempts:
+			decision.Action = RetryActionOnHold
+			decision.Status = LifecycleStatusOnHold
+			decision.Reason = fmt.Sprintf("failed %d times, the limit is %d", attempts, policy.MaxAttempts)
+			plan.OnHold = append(plan.OnHold, decision)
+			continue
+		}
+
+		switch {
+		case lastError.NextRetryAt != nil:
+			decision.NextRetryAt = *lastError.NextRetryAt
+		case lastError.LastFailedAt != nil:
+			decision.NextRetryAt = lastError.LastFailedAt.Add(policy.Delay(attempts))
+		default:
+			decision.NextRetryAt = now
+		}
+
+		if decision.NextRetryAt.After(now) {
+			decision.Action = RetryActionWait
+			decision.Reason = fmt.Sprintf("next retry in %s", decision.NextRetryAt.Sub(now).Round(time.Second))
+			plan.Wait = append(plan.Wait, decision)
+			continue
+		}
+
+		decision.Action = RetryActionRetryNow
+		decision.Status = stage.RetryStatus()
+		decision.Reason = fmt.Sprintf("retry %d of %s", attempts+1, stage)
+		plan.RetryNow = append(plan.RetryNow, decision)
+	}
+
+	for _, decisions := range [][]RetryDecision{plan.RetryNow, plan.Wait, plan.OnHold} {
+		sort.SliceStable(decisions, func(i, j int) bool {
+			return decisions[i].NextRetryAt.Before(decisions[j].NextRetryAt)
+		})
+	}
+
+	return plan
+}
+
+func describeFailure(lastError LastError, outcome string) string {
+	if lastError.Code == "" {
+		return "failure " + outcome
+	}
+	return fmt.Sprintf("failure %s %s", lastError.Code, outcome)
+}
@@ -0,0 +1,101 @@
+package blocks
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlock_RecordFailure(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := NewEmptyBlock()
+
+	b.RecordFailure(Failure{Stage: FailureStageEnrichment, Code: "timeout", Message: "scraper timed out", At: start})
+	b.RecordFailure(Failure{Stage: FailureStageEnrichment, Code: "timeout", Message: "scraper timed out again", At: start.Add(time.Minute)})
+
+	assert.Equal(t, 2, b.LastError.Attempts)
+	assert.Equal(t, start, *b.LastError.FirstFailedAt)
+	assert.Equal(t, start.Add(time.Minute), *b.LastError.LastFailedAt)
+	assert.Equal(t, "scraper timed out again", b.LastError.Message)
+
+	b.RecordFailure(Failure{Stage: FailureStageRouting, Code: "no_space", At: start.Add(time.Hour)})
+	assert.Equal(t, 1, b.LastError.Attempts)
+	assert.Equal(t, start.Add(time.Hour), *b.LastError.FirstFailedAt)
+
+	// The message-only JSON format is still understood
+	var legacy LastError
+	assert.NoError(t, json.Unmarshal([]byte(`{"message":"boom"}`), &legacy))
+	assert.Equal(t, LastError{Message: "boom"}, legacy)
+
+	b.ClearFailure()
+	assert.Equal(t, LastError{}, b.LastError)
+}
+
+func TestBackoffPolicy_Delay(t *testing.T) {
+	policy := DefaultBackoffPolicy()
+
+	assert.Equal(t, time.Duration(0), policy.Delay(0))
+	assert.Equal(t, time.Minute, policy.Delay(1))
+	assert.Equal(t, 4*time.Minute, policy.Delay(3))
+	assert.Equal(t, 6*time.Hour, policy.Delay(20))
+}
+
+func TestPlanRetries(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	policy := DefaultBackoffPolicy()
+
+	failed := func(status LifecycleStatus, f Failure, attempts int) Block {
+		b := NewEmptyBlock()
+		b.LifecycleStatus = status
+		for i := 0; i < attempts; i++ {
+			b.RecordFailure(f)
+		}
+		return b
+	}
+
+	due := failed(LifecycleStatusEnrichmentFailed, Failure{Stage: FailureStageEnrichment, At: now.Add(-10 * time.Minute)}, 2)
+	waiting := failed(LifecycleStatusProcessingFailed, Failure{Stage: FailureStageProcessing, At: now.Add(-30 * time.Second)}, 1)
+	exhausted := failed(LifecycleStatusRoutingFailed, Failure{Stage: FailureStageRouting, At: now.Add(-time.Hour)}, 5)
+	permanent := failed(LifecycleStatusPostProcessingFailed, Failure{Stage: FailureStagePostProcessing, Code: "invalid_input", NonRetryable: true, At: now}, 1)
+	routing := failed(LifecycleStatusRoutingFailed, Failure{Stage: FailureStageRouting, At: now.Add(-20 * time.Minute)}, 1)
+	legacy := NewEmptyBlock()
+	legacy.LifecycleStatus = LifecycleStatusPreProcessingFailed
+	// Failures recorded before the retry fields existed only have a message and are retried
+	legacy.LastError = LastError{Message: "boom"}
+	// The processing failure was resolved, but enrichment failed later without recording a failure
+	stale := failed(LifecycleStatusEnrichmentFailed, Failure{Stage: FailureStageProcessing, NonRetryable: true, At: now.Add(-time.Hour)}, 5)
+	healthy := NewEmptyBlock()
+	healthy.LifecycleStatus = LifecycleStatusIndexed
+
+	plan := PlanRetries([]Block{due, waiting, exhausted, permanent, routing, legacy, stale, healthy}, policy, now)
+
+	assert.Equal(t, []RetryDecision{
+		// Retrying routing returns the block to the state it is routed from
+		{BlockID: routing.ID, Stage: FailureStageRouting, Action: RetryActionRetryNow, Attempts: 1, Status: LifecycleStatusTransformed, NextRetryAt: now.Add(-19 * time.Minute), Reason: "retry 2 of routing"},
+		{BlockID: due.ID, Stage: FailureStageEnrichment, Action: RetryActionRetryNow, Attempts: 2, Status: LifecycleStatusEnriching, NextRetryAt: now.Add(-8 * time.Minute), Reason: "retry 3 of enrichment"},
+		{BlockID: legacy.ID, Stage: FailureStagePreProcessing, Action: RetryActionRetryNow, Attempts: 1, Status: LifecycleStatusPreProcessing, NextRetryAt: now, Reason: "retry 2 of pre_processing"},
+		{BlockID: stale.ID, Stage: FailureStageEnrichment, Action: RetryActionRetryNow, Attempts: 1, Status: LifecycleStatusEnriching, NextRetryAt: now, Reason: "retry 2 of enrichment"},
+	}, plan.RetryNow)
+
+	assert.Equal(t, []RetryDecision{
+		{BlockID: waiting.ID, Stage: FailureStageProcessing, Action: RetryActionWait, Attempts: 1, NextRetryAt: now.Add(30 * time.Second), Reason: "next retry in 30s"},
+	}, plan.Wait)
+
+	assert.Len(t, plan.OnHold, 2)
+	assert.Equal(t, exhausted.ID, plan.OnHold[0].BlockID)
+	assert.Equal(t, "failed 5 times, the limit is 5", plan.OnHold[0].Reason)
+	assert.Equal(t, permanent.ID, plan.OnHold[1].BlockID)
+	assert.Equal(t, "failure invalid_input is not retryable", plan.OnHold[1].Reason)
+	assert.Equal(t, LifecycleStatusOnHold, plan.OnHold[1].Status)
+
+	// Every planned status is a legal transition
+	for _, decision := range append(plan.RetryNow, plan.OnHold...) {
+		for _, b := range []Block{due, exhausted, permanent, routing, legacy, stale} {
+			if b.ID == decision.BlockID {
+				assert.True(t, DefaultLifecycleMachine().CanTransition(b.LifecycleStatus, decision.Status))
+			}
+		}
+	}
+}
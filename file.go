@@ -125,3 +125,13 @@ func GetFileProperties() []string {
 		PropertyKeyEnriched,
 	}
 }
+
+func init() {
+	mustRegisterType(TypeDefinition{
+		DataType:     TypeFile,
+		RenderFunc:   renderWith(RenderFileProperties),
+		PropertyKeys: GetFileProperties(),
+		Content:      BlockContentTypeStructural,
+		Final:        true,
+	})
+}
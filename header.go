@@ -57,3 +57,14 @@ func GetHeaderProperties() []string {
 		PropertyKeyTitle,
 	}
 }
+
+func init() {
+	for _, t := range []DataType{TypeHeader1, TypeHeader2, TypeHeader3, TypeHeader4, TypeHeader5, TypeHeader6} {
+		mustRegisterType(TypeDefinition{
+			DataType:     t,
+			RenderFunc:   renderWith(RenderHeaderProperties),
+			PropertyKeys: GetHeaderProperties(),
+			Content:      BlockContentTypeTextual,
+		})
+	}
+}
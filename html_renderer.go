@@ -0,0 +1,293 @@
+package blocks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HTMLTemplates overrides the HTML of specific block types.
+// Templates are executed with HTMLBlockData and are responsible for including .Children.
+type HTMLTemplates map[DataType]*template.Template
+
+// HTMLBlockData is the data passed to the HTML template of a block
+type HTMLBlockData struct {
+	ID         string
+	Type       DataType
+	Properties Properties
+
+	// Children contains the already rendered HTML of the child blocks
+	Children template.HTML
+
+	// Text contains the plain text rendering of the block, used by the fallback template
+	Text string
+}
+
+// String returns the first value of the property as a string
+func (d HTMLBlockData) String(key string) string {
+	value, _ := d.Properties.GetString(key)
+	return value
+}
+
+// Strings returns all values of the property as strings, flattening nested arrays
+func (d HTMLBlockData) Strings(key string) []string {
+	values, ok := d.Properties.GetArray(key)
+	if !ok {
+		return nil
+	}
+	return flattenArray(values)
+}
+
+// Lines returns the property split into lines
+func (d HTMLBlockData) Lines(key string) []string {
+	value := d.String(key)
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, "\n")
+}
+
+// Int returns the first value of the property as an int
+func (d HTMLBlockData) Int(key string) int {
+	value, _ := d.Properties.GetInt(key)
+	return value
+}
+
+// Float returns the first value of the property as a float64
+func (d HTMLBlockData) Float(key string) float64 {
+	value, _ := d.Properties.GetFloat(key)
+	return value
+}
+
+// Bool returns the first value of the property as a bool
+func (d HTMLBlockData) Bool(key string) bool {
+	value, _ := d.Properties.GetBool(key)
+	return value
+}
+
+// Time returns the first value of the property as a time.Time, or the zero time
+func (d HTMLBlockData) Time(key string) time.Time {
+	value, _ := d.Properties.GetTime(key)
+	return value
+}
+
+// defaultHTMLTemplateSources contains the built-in template of every type with a dedicated HTML representation
+var defaultHTMLTemplateSources = map[DataType]string{
+	TypePage: `<article class="block block-page">{{with .String "title"}}<h1>{{.}}</h1>{{end}}{{.Children}}</article>`,
+
+	TypeParagraph: `<p>{{range $i, $line := .Lines "title"}}{{if $i}}<br>{{end}}{{$line}}{{end}}</p>{{.Children}}`,
+	TypeHeader1:   `<h1>{{.String "title"}}</h1>{{.Children}}`,
+	TypeHeader2:   `<h2>{{.String "title"}}</h2>{{.Children}}`,
+	TypeHeader3:   `<h3>{{.String "title"}}</h3>{{.Children}}`,
+	TypeHeader4:   `<h4>{{.String "title"}}</h4>{{.Children}}`,
+	TypeHeader5:   `<h5>{{.String "title"}}</h5>{{.Children}}`,
+	TypeHeader6:   `<h6>{{.String "title"}}</h6>{{.Children}}`,
+	TypeLine:      `<hr>`,
+	TypeQuote:     `<blockquote>{{range $i, $line := .Lines "title"}}{{if $i}}<br>{{end}}{{$line}}{{end}}</blockquote>{{.Children}}`,
+	TypeCode:      `<pre><code{{with .String "language"}} class="language-{{.}}"{{end}}>{{.String "title"}}</code></pre>{{.Children}}`,
+
+	TypeBulletListItem:   `<li>{{range $i, $line := .Lines "title"}}{{if $i}}<br>{{end}}{{$line}}{{end}}{{.Children}}</li>`,
+	TypeNumberedListItem: `<li>{{range $i, $line := .Lines "title"}}{{if $i}}<br>{{end}}{{$line}}{{end}}{{.Children}}</li>`,
+	TypeToDo: `<li class="block-to-do"><label><input type="checkbox" disabled{{if .Bool "checked"}} checked{{end}}> {{.String "title"}}</label>` +
+		`{{$due := .Time "target_datetime"}}{{if not $due.IsZero}} <time datetime="{{$due.Format "2006-01-02T15:04:05Z07:00"}}">{{$due.Format "2006-01-02 15:04"}}</time>{{end}}{{.Children}}</li>`,
+
+	TypeImage: `<figure class="block block-image">{{with .String "public_url"}}<img src="{{.}}" alt="{{$.String "description"}}">{{end}}` +
+		`{{with .String "transcription"}}<figcaption>{{.}}</figcaption>{{end}}</figure>{{.Children}}`,
+	TypeAudio: `<figure class="block block-audio"><audio controls src="{{.String "public_url"}}"></audio>` +
+		`{{with .String "filename"}}<figcaption>{{.}}{{with $.String "extension"}}.{{.}}{{end}}</figcaption>{{end}}</figure>{{.Children}}`,
+	TypeVideo: `<figure class="block block-video"><video controls src="{{.String "public_url"}}"></video>` +
+		`{{with .String "filename"}}<figcaption>{{.}}{{with $.String "extension"}}.{{.}}{{end}}</figcaption>{{end}}</figure>{{.Children}}`,
+	TypeFile: `<p class="block block-file"><a href="{{.String "public_url"}}" download>{{with .String "filename"}}{{.}}{{with $.String "extension"}}.{{.}}{{end}}{{else}}{{$.String "public_url"}}{{end}}</a>` +
+		`{{with .Int "size"}} <span class="block-file-size">{{.}} bytes</span>{{end}}</p>{{.Children}}`,
+
+	TypeLink:      htmlLinkCardTemplate("block-link"),
+	TypeYouTube:   htmlLinkCardTemplate("block-youtube"),
+	TypeInstagram: htmlLinkCardTemplate("block-instagram"),
+	TypeTweet:     htmlLinkCardTemplate("block-tweet"),
+
+	TypeMovie: `<div class="block block-card block-movie">{{with .String "url_image"}}<img src="{{.}}" alt="{{$.String "title"}}">{{end}}` +
+		`<h3>{{with .String "url"}}<a href="{{.}}">{{$.String "title"}}</a>{{else}}{{.String "title"}}{{end}}{{with .Int "release_year"}} ({{.}}){{end}}</h3>` +
+		`{{with .String "tagline"}}<p class="block-tagline"><em>{{.}}</em></p>{{end}}<dl>` +
+		`{{with .Float "rating"}}<dt>Rating</dt><dd>{{printf "%.1f" .}}</dd>{{end}}` +
+		`{{with .Int "runtime"}}<dt>Runtime</dt><dd>{{.}} min</dd>{{end}}` +
+		`{{with .Strings "genres"}}<dt>Genres</dt><dd>{{join . ", "}}</dd>{{end}}` +
+		`{{with .Strings "directors"}}<dt>Directors</dt><dd>{{join . ", "}}</dd>{{end}}` +
+		`{{with .Strings "cast"}}<dt>Cast</dt><dd>{{join . ", "}}</dd>{{end}}</dl>` +
+		`{{with .String "description"}}<p>{{.}}</p>{{end}}</div>{{.Children}}`,
+	TypeSeries: `<div class="block block-card block-series">{{with .String "url_image"}}<img src="{{.}}" alt="{{$.String "title"}}">{{end}}` +
+		`<h3>{{with .String "url"}}<a href="{{.}}">{{$.String "title"}}</a>{{else}}{{.String "title"}}{{end}}{{with .Int "first_air_year"}} ({{.}}){{end}}</h3><dl>` +
+		`{{with .Float "rating"}}<dt>Rating</dt><dd>{{printf "%.1f" .}}</dd>{{end}}` +
+		`{{with .Int "number_of_seasons"}}<dt>Seasons</dt><dd>{{.}}</dd>{{end}}` +
+		`{{with .Strings "genres"}}<dt>Genres</dt><dd>{{join . ", "}}</dd>{{end}}` +
+		`{{with .Strings "creators"}}<dt>Creators</dt><dd>{{join . ", "}}</dd>{{end}}` +
+		`{{with .Strings "networks"}}<dt>Networks</dt><dd>{{join . ", "}}</dd>{{end}}</dl>` +
+		`{{with .String "description"}}<p>{{.}}</p>{{end}}</div>{{.Children}}`,
+	TypeBook: `<div class="block block-card block-book">{{with .String "url_image"}}<img src="{{.}}" alt="{{$.String "title"}}">{{end}}` +
+		`<h3>{{with .String "url"}}<a href="{{.}}">{{$.String "title"}}</a>{{else}}{{.String "title"}}{{end}}</h3>` +
+		`{{with .Strings "author_name"}}<p class="block-book-authors">{{join . ", "}}</p>{{end}}<dl>` +
+		`{{with .String "publisher"}}<dt>Publisher</dt><dd>{{.}}</dd>{{end}}` +
+		`{{with .Int "page_count"}}<dt>Pages</dt><dd>{{.}}</dd>{{end}}` +
+		`{{with .String "isbn"}}<dt>ISBN</dt><dd>{{.}}</dd>{{end}}</dl>` +
+		`{{with .String "description"}}<p>{{.}}</p>{{end}}</div>{{.Children}}`,
+	TypePlace: `<div class="block block-card block-place">{{with .String "url_image"}}<img src="{{.}}" alt="{{$.String "title"}}">{{end}}` +
+		`<h3>{{with .String "url"}}<a href="{{.}}">{{$.String "title"}}</a>{{else}}{{.String "title"}}{{end}}</h3><dl>` +
+		`{{with .String "place_type"}}<dt>Type</dt><dd>{{.}}</dd>{{end}}` +
+		`{{with .String "address"}}<dt>Address</dt><dd>{{with $.String "map_url"}}<a href="{{.}}">{{$.String "address"}}</a>{{else}}{{.}}{{end}}</dd>{{end}}` +
+		`{{with .String "phone_number"}}<dt>Phone</dt><dd>{{.}}</dd>{{end}}` +
+		`{{with .Float "rating"}}<dt>Rating</dt><dd>{{printf "%.1f" .}}</dd>{{end}}</dl>` +
+		`{{with .String "description"}}<p>{{.}}</p>{{end}}</div>{{.Children}}`,
+	TypePerson: `<div class="block block-card block-person">{{with .String "url_image"}}<img src="{{.}}" alt="{{$.String "first_name"}}">{{end}}` +
+		`<h3>{{.String "first_name"}}{{with .String "last_name"}} {{.}}{{end}}</h3>` +
+		`{{with .String "relation_type"}}<p class="block-person-relation">{{.}}</p>{{end}}` +
+		`{{with .String "description"}}<p>{{.}}</p>{{end}}</div>{{.Children}}`,
+	TypeEmail: `<article class="block block-email"><h3>{{.String "subject"}}</h3><dl>` +
+		`{{with .String "from"}}<dt>From</dt><dd>{{.}}</dd>{{end}}` +
+		`{{with .Strings "to"}}<dt>To</dt><dd>{{join . ", "}}</dd>{{end}}` +
+		`{{$date := .Time "date"}}{{if not $date.IsZero}}<dt>Date</dt><dd><time datetime="{{$date.Format "2006-01-02T15:04:05Z07:00"}}">{{$date.Format "Jan 2, 2006 3:04 PM"}}</time></dd>{{end}}</dl>` +
+		`<div class="block-email-body">{{range $i, $line := .Lines "text"}}{{if $i}}<br>{{end}}{{$line}}{{end}}</div></article>{{.Children}}`,
+}
+
+// htmlFallbackTemplateSource is used for types without a dedicated HTML representation
+const htmlFallbackTemplateSource = `<div class="block block-{{.Type}}">{{with .Text}}<p>{{range $i, $line := splitLines .}}{{if $i}}<br>{{end}}{{$line}}{{end}}</p>{{end}}{{.Children}}</div>`
+
+// htmlLinkCardTemplate returns a link card template with the given CSS class
+func htmlLinkCardTemplate(class string) string {
+	return `<div class="block block-card ` + class + `">{{with .String "url_image"}}<img src="{{.}}" alt="{{$.String "title"}}">{{end}}` +
+		`<a href="{{.String "url"}}">{{with .String "title"}}{{.}}{{else}}{{$.String "url"}}{{end}}</a>` +
+		`{{with .String "description"}}<p>{{.}}</p>{{end}}</div>{{.Children}}`
+}
+
+var htmlTemplateFuncs = template.FuncMap{
+	"join":       strings.Join,
+	"splitLines": func(s string) []string { return strings.Split(s, "\n") },
+}
+
+var defaultHTMLTemplates, htmlFallbackTemplate = parseDefaultHTMLTemplates()
+
+func parseDefaultHTMLTemplates() (HTMLTemplates, *template.Template) {
+	templates := make(HTMLTemplates, len(defaultHTMLTemplateSources))
+	for dataType, source := range defaultHTMLTemplateSources {
+		templates[dataType] = template.Must(template.New(dataType.String()).Funcs(htmlTemplateFuncs).Parse(source))
+	}
+	fallback := template.Must(template.New("fallback").Funcs(htmlTemplateFuncs).Parse(htmlFallbackTemplateSource))
+	return templates, fallback
+}
+
+// NewHTMLTemplate parses an override template, making the built-in template functions
+// (join, splitLines) available to it
+func NewHTMLTemplate(name string, source string) (*template.Template, error) {
+	return template.New(name).Funcs(htmlTemplateFuncs).Parse(source)
+}
+
+// RenderHTML renders a block and all of its descendants as semantic, escaped HTML.
+// Consecutive list items are grouped into <ul> or <ol> elements.
+// Templates in overrides replace the built-in template of their type; overrides may be nil.
+func RenderHTML(ctx context.Context, b Block, lookupBlocks map[uuid.UUID]Block, overrides HTMLTemplates) (string, error) {
+	html, err := renderHTMLSequence(ctx, []Block{b}, lookupBlocks, overrides, make(map[uuid.UUID]bool))
+	if err != nil {
+		return "", err
+	}
+	return string(html), nil
+}
+
+// renderHTMLSequence renders sibling blocks and wraps runs of list items in list elements
+func renderHTMLSequence(ctx context.Context, siblings []Block, lookupBlocks map[uuid.UUID]Block, overrides HTMLTemplates, visitedBlocks map[uuid.UUID]bool) (template.HTML, error) {
+	var buf bytes.Buffer
+	openList := ""
+
+	for _, block := range siblings {
+		// Skip blocks we have already rendered to prevent cycles
+		if visitedBlocks[block.ID] {
+			continue
+		}
+		visitedBlocks[block.ID] = true
+
+		list := htmlListElement(block.Type)
+		if list != openList {
+			if openList != "" {
+				buf.WriteString("</" + htmlListTag(openList) + ">")
+			}
+			if list != "" {
+				buf.WriteString(list)
+			}
+			openList = list
+		}
+
+		rendered, err := renderHTMLBlock(ctx, block, lookupBlocks, overrides, visitedBlocks)
+		if err != nil {
+			return "", err
+		}
+		buf.WriteString(string(rendered))
+	}
+
+	if openList != "" {
+		buf.WriteString("</" + htmlListTag(openList) + ">")
+	}
+
+	return template.HTML(buf.String()), nil
+}
+
+// renderHTMLBlock renders a single block with its children through the template of its type
+func renderHTMLBlock(ctx context.Context, b Block, lookupBlocks map[uuid.UUID]Block, overrides HTMLTemplates, visitedBlocks map[uuid.UUID]bool) (template.HTML, error) {
+	children := make([]Block, 0, len(b.Content))
+	for _, childID := range b.Content {
+		if child, exists := lookupBlocks[childID]; exists {
+			children = append(children, child)
+		}
+	}
+
+	renderedChildren, err := renderHTMLSequence(ctx, children, lookupBlocks, overrides, visitedBlocks)
+	if err != nil {
+		return "", err
+	}
+
+	data := HTMLBlockData{
+		ID:         b.ID.String(),
+		Type:       b.Type,
+		Properties: b.Properties,
+		Children:   renderedChildren,
+	}
+
+	tmpl, ok := overrides[b.Type]
+	if !ok {
+		tmpl, ok = defaultHTMLTemplates[b.Type]
+	}
+	if !ok {
+		tmpl = htmlFallbackTemplate
+		data.Text = RenderProperties(ctx, b)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render %s block %s as HTML: %w", b.Type, b.ID, err)
+	}
+
+	return template.HTML(buf.String()), nil
+}
+
+// htmlListElement returns the opening list element wrapping the given type, or "" for non-list types
+func htmlListElement(t DataType) string {
+	switch t {
+	case TypeBulletListItem:
+		return "<ul>"
+	case TypeNumberedListItem:
+		return "<ol>"
+	case TypeToDo:
+		return `<ul class="block-to-do-list">`
+	default:
+		return ""
+	}
+}
+
+// htmlListTag returns the tag name of an opening list element
+func htmlListTag(element string) string {
+	if strings.HasPrefix(element, "<ol") {
+		return "ol"
+	}
+	return "ul"
+}
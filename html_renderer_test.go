@@ -0,0 +1,94 @@
+package blocks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderHTML(t *testing.T) {
+	ctx := context.Background()
+
+	lookupFor := func(blocks []Block) map[uuid.UUID]Block {
+		lookup := make(map[uuid.UUID]Block, len(blocks))
+		for _, b := range blocks {
+			lookup[b.ID] = b
+		}
+		return lookup
+	}
+
+	t.Run("document with lists, headings and tasks", func(t *testing.T) {
+		parsed, err := NewBlocksFromMarkdown("## Plan <today>\n\n- one\n  - nested\n- [x] done\n\n1. first\n2. second\n\n---")
+		assert.NoError(t, err)
+
+		html, err := RenderHTML(ctx, parsed[0], lookupFor(parsed), nil)
+		assert.NoError(t, err)
+		assert.Equal(t, `<article class="block block-page">`+
+			`<h2>Plan &lt;today&gt;</h2>`+
+			`<ul><li>one<ul><li>nested</li></ul></li></ul>`+
+			`<ul class="block-to-do-list"><li class="block-to-do"><label><input type="checkbox" disabled checked> done</label></li></ul>`+
+			`<ol><li>first</li><li>second</li></ol>`+
+			`<hr>`+
+			`</article>`, html)
+	})
+
+	t.Run("image figure and unsafe link", func(t *testing.T) {
+		image := NewEmptyBlock()
+		image.Type = TypeImage
+		image.Properties[PropertyKeyPublicURL] = []interface{}{"https://example.com/cat.png"}
+		image.Properties[PropertyKeyDescription] = []interface{}{`A "cat"`}
+
+		html, err := RenderHTML(ctx, image, nil, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, `<figure class="block block-image"><img src="https://example.com/cat.png" alt="A &#34;cat&#34;"></figure>`, html)
+
+		link := NewEmptyBlock()
+		link.Type = TypeLink
+		link.Properties[PropertyKeyURL] = []interface{}{"javascript:alert(1)"}
+		link.Properties[PropertyKeyTitle] = []interface{}{"<script>"}
+
+		html, err = RenderHTML(ctx, link, nil, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, `<div class="block block-card block-link"><a href="#ZgotmplZ">&lt;script&gt;</a></div>`, html)
+	})
+
+	t.Run("movie card", func(t *testing.T) {
+		movie := NewEmptyBlock()
+		movie.Type = TypeMovie
+		title, url, rating := "The Matrix", "https://www.imdb.com/title/tt0133093/", "8.7"
+		year := 1999
+		genres := []string{"Action", "Sci-Fi"}
+		assert.NoError(t, AddMovieProperties(&movie, &title, nil, nil, &url, nil, nil, &year, &rating, nil, nil, nil, nil, &genres, nil, nil, nil, true))
+
+		html, err := RenderHTML(ctx, movie, nil, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, `<div class="block block-card block-movie">`+
+			`<h3><a href="https://www.imdb.com/title/tt0133093/">The Matrix</a> (1999)</h3>`+
+			`<dl><dt>Rating</dt><dd>8.7</dd><dt>Genres</dt><dd>Action, Sci-Fi</dd></dl>`+
+			`</div>`, html)
+	})
+
+	t.Run("template override", func(t *testing.T) {
+		paragraph := NewEmptyBlock()
+		paragraph.Type = TypeParagraph
+		paragraph.Properties[PropertyKeyTitle] = []interface{}{"Hello <world>"}
+
+		tmpl, err := NewHTMLTemplate("paragraph", `<p class="custom">{{.String "title"}}</p>`)
+		assert.NoError(t, err)
+
+		html, err := RenderHTML(ctx, paragraph, nil, HTMLTemplates{TypeParagraph: tmpl})
+		assert.NoError(t, err)
+		assert.Equal(t, `<p class="custom">Hello &lt;world&gt;</p>`, html)
+	})
+
+	t.Run("fallback for types without template", func(t *testing.T) {
+		fragment := NewEmptyBlock()
+		fragment.Properties[PropertyKeyTitle] = []interface{}{"ignored"}
+
+		html, err := RenderHTML(ctx, fragment, nil, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, `<div class="block block-fragment"></div>`, html)
+	})
+}
@@ -86,9 +86,13 @@ func RenderImageProperties(b Block) string {
 		parts = append(parts, fmt.Sprintf("**File:** %s", headerText))
 	}
 
-	// Add public URL if available
+	// Add public URL if available, using the description as alt text
 	if hasPublicURL && publicURL != "" {
-		parts = append(parts, fmt.Sprintf("![Image](%s)", publicURL))
+		alt := "Image"
+		if description, ok := b.Properties.GetString(PropertyKeyDescription); ok && description != "" {
+			alt = description
+		}
+		parts = append(parts, fmt.Sprintf("![%s](%s)", alt, publicURL))
 	}
 
 	// Add size information if available
@@ -123,5 +127,16 @@ func GetImageProperties() []string {
 		PropertyKeyExtension,
 		PropertyKeyTranscribed,
 		PropertyKeyEnriched,
+		PropertyKeyDescription,
 	}
 }
+
+func init() {
+	mustRegisterType(TypeDefinition{
+		DataType:     TypeImage,
+		RenderFunc:   renderWith(RenderImageProperties),
+		PropertyKeys: GetImageProperties(),
+		Content:      BlockContentTypeStructural,
+		Final:        true,
+	})
+}
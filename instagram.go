@@ -111,3 +111,13 @@ func GetInstagramProperties() []string {
 		PropertyKeyEnriched,
 	}
 }
+
+func init() {
+	mustRegisterType(TypeDefinition{
+		DataType:     TypeInstagram,
+		RenderFunc:   renderWith(RenderInstagramProperties),
+		PropertyKeys: GetInstagramProperties(),
+		Content:      BlockContentTypeStructural,
+		Schema:       NewPropertySchema(GetInstagramProperties()...).Require(PropertyKeyURL),
+	})
+}
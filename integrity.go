@@ -0,0 +1,405 @@
+package blocks
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// IntegrityCategory categorizes a violation of the hierarchy invariants
+type IntegrityCategory string
+
+func (c IntegrityCategory) String() string {
+	return string(c)
+}
+
+const (
+	// IntegrityCategoryOrphan is used when ParentID points to a block that does not exist
+	IntegrityCategoryOrphan IntegrityCategory = "orphan"
+	// IntegrityCategoryMissingChild is used when Content references a block that does not exist
+	IntegrityCategoryMissingChild IntegrityCategory = "missing_child"
+	// IntegrityCategoryDuplicateChild is used when Content references the same block more than once
+	IntegrityCategoryDuplicateChild IntegrityCategory = "duplicate_child"
+	// IntegrityCategoryParentMismatch is used when a block is in the Content of a block that is not its parent
+	IntegrityCategoryParentMismatch IntegrityCategory = "parent_mismatch"
+	// IntegrityCategoryMissingFromContent is used when a block is not in the Content of its parent
+	IntegrityCategoryMissingFromContent IntegrityCategory = "missing_from_content"
+	// IntegrityCategoryStaleChildrenRecursive is used when ChildrenRecursive differs from the actual descendants
+	IntegrityCategoryStaleChildrenRecursive IntegrityCategory = "stale_children_recursive"
+	// IntegrityCategoryWrongRootParent is used when RootParentID does not point to the topmost ancestor
+	IntegrityCategoryWrongRootParent IntegrityCategory = "wrong_root_parent"
+	// IntegrityCategoryCycle is used when following ParentID leads back to the same block
+	IntegrityCategoryCycle IntegrityCategory = "cycle"
+)
+
+// IntegrityIssue describes a single invariant violation
+type IntegrityIssue struct {
+	Category  IntegrityCategory `json:"category"`
+	BlockID   uuid.UUID         `json:"block_id"`
+	RelatedID uuid.UUID         `json:"related_id,omitempty"`
+	Message   string            `json:"message"`
+}
+
+// RepairFix sets a single hierarchy field of a block to a new value.
+// Value is a *uuid.UUID for parent_id and root_parent_id and a []uuid.UUID for content and children_recursive.
+type RepairFix struct {
+	BlockID uuid.UUID   `json:"block_id"`
+	Field   string      `json:"field"`
+	Value   interface{} `json:"value"`
+}
+
+// IntegrityReport contains the issues found in a set of blocks and the fixes that repair them
+type IntegrityReport struct {
+	Issues  []IntegrityIssue `json:"issues"`
+	Repairs []RepairFix      `json:"repairs"`
+}
+
+// HasIssues reports whether any invariant violation was found
+func (r IntegrityReport) HasIssues() bool {
+	return len(r.Issues) > 0
+}
+
+// IssuesByCategory groups the issues by category
+func (r IntegrityReport) IssuesByCategory() map[IntegrityCategory][]IntegrityIssue {
+	grouped := make(map[IntegrityCategory][]IntegrityIssue)
+	for _, issue := range r.Issues {
+		grouped[issue.Category] = append(grouped[issue.Category], issue)
+	}
+	return grouped
+}
+
+// CheckIntegrity scans a set of blocks, reports every hierarchy invariant violation and plans the repairs.
+// ParentID is considered the source of truth: Content is rebuilt from it, blocks with a missing parent
+// become roots and cycles are broken at the block with the smallest ID.
+// ChildrenRecursive and RootParentID are then recomputed from the repaired hierarchy.
+func CheckIntegrity(blocks []Block) (IntegrityReport, error) {
+	tree, err := NewBlockTree(blocks)
+	if err != nil {
+		return IntegrityReport{}, err
+	}
+
+	checker := &integrityChecker{tree: tree, parents: make(map[uuid.UUID]uuid.UUID)}
+	checker.checkLinks()
+	checker.checkCycles()
+	checker.planHierarchy()
+
+	return checker.report, nil
+}
+
+// ApplyRepairs applies the fixes to the given blocks and returns the blocks that changed
+func ApplyRepairs(blocks []Block, fixes []RepairFix) ([]Block, error) {
+	byID := make(map[uuid.UUID]int, len(blocks))
+	for i, b := range blocks {
+		byID[b.ID] = i
+	}
+
+	changed := make(map[uuid.UUID]Block)
+	var order []uuid.UUID
+	for _, fix := range fixes {
+		b, exists := changed[fix.BlockID]
+		if !exists {
+			i, ok := byID[fix.BlockID]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", ErrBlockNotFound, fix.BlockID)
+			}
+			b = blocks[i]
+			order = append(order, fix.BlockID)
+		}
+
+		if err := applyRepairFix(&b, fix); err != nil {
+			return nil, err
+		}
+		changed[fix.BlockID] = b
+	}
+
+	result := make([]Block, 0, len(order))
+	for _, id := range order {
+		result = append(result, changed[id])
+	}
+	return result, nil
+}
+
+func applyRepairFix(b *Block, fix RepairFix) error {
+	switch fix.Field {
+	case BlockPropertyParentID, BlockPropertyRootParentID:
+		id, ok := fix.Value.(*uuid.UUID)
+		if !ok && fix.Value != nil {
+			return fmt.Errorf("invalid value %T for %s", fix.Value, fix.Field)
+		}
+		if fix.Field == BlockPropertyParentID {
+			b.ParentID = copyIDPointer(id)
+		} else {
+			b.RootParentID = copyIDPointer(id)
+		}
+	case BlockPropertyContent, BlockPropertyChildrenRecursive:
+		ids, ok := fix.Value.([]uuid.UUID)
+		if !ok {
+			return fmt.Errorf("invalid value %T for %s", fix.Value, fix.Field)
+		}
+		if fix.Field == BlockPropertyContent {
+			b.Content = append([]uuid.UUID{}, ids...)
+		} else {
+			b.ChildrenRecursive = append([]uuid.UUID{}, ids...)
+		}
+	default:
+		return fmt.Errorf("cannot repair field %s", fix.Field)
+	}
+	return nil
+}
+
+// integrityChecker holds the state of a single integrity check
+type integrityChecker struct {
+	tree   *BlockTree
+	report IntegrityReport
+
+	// parents is the repaired parent of every block that has one
+	parents map[uuid.UUID]uuid.UUID
+}
+
+func (c *integrityChecker) addIssue(category IntegrityCategory, blockID, relatedID uuid.UUID, message string) {
+	c.report.Issues = append(c.report.Issues, IntegrityIssue{
+		Category:  category,
+		BlockID:   blockID,
+		RelatedID: relatedID,
+		Message:   message,
+	})
+}
+
+// checkLinks compares ParentID with Content for every block
+func (c *integrityChecker) checkLinks() {
+	for _, id := range c.tree.order {
+		b := c.tree.blocks[id]
+
+		if b.ParentID != nil {
+			parent, exists := c.tree.blocks[*b.ParentID]
+			switch {
+			case !exists:
+				c.addIssue(IntegrityCategoryOrphan, id, *b.ParentID,
+					fmt.Sprintf("parent %s does not exist", *b.ParentID))
+			case !containsID(parent.Content, id):
+				c.parents[id] = *b.ParentID
+				c.addIssue(IntegrityCategoryMissingFromContent, id, *b.ParentID,
+					fmt.Sprintf("block is missing from the content of its parent %s", *b.ParentID))
+			default:
+				c.parents[id] = *b.ParentID
+			}
+		}
+
+		seen := make(map[uuid.UUID]bool, len(b.Content))
+		for _, childID := range b.Content {
+			if seen[childID] {
+				c.addIssue(IntegrityCategoryDuplicateChild, id, childID,
+					fmt.Sprintf("child %s appears more than once in content", childID))
+				continue
+			}
+			seen[childID] = true
+
+			child, exists := c.tree.blocks[childID]
+			switch {
+			case !exists:
+				c.addIssue(IntegrityCategoryMissingChild, id, childID,
+					fmt.Sprintf("child %s does not exist", childID))
+			case child.ParentID == nil || *child.ParentID != id:
+				c.addIssue(IntegrityCategoryParentMismatch, id, childID,
+					fmt.Sprintf("child %s has a different parent", childID))
+			}
+		}
+	}
+}
+
+// checkCycles finds ParentID cycles and breaks each of them at the block with the smallest ID
+func (c *integrityChecker) checkCycles() {
+	inCycle := make(map[uuid.UUID]bool)
+
+	for _, id := range c.tree.order {
+		if inCycle[id] {
+			continue
+		}
+
+		path := []uuid.UUID{id}
+		position := map[uuid.UUID]int{id: 0}
+		current := id
+		for {
+			parentID, exists := c.parents[current]
+			if !exists || inCycle[parentID] {
+				break
+			}
+			if start, visited := position[parentID]; visited {
+				cycle := append([]uuid.UUID{}, path[start:]...)
+				for _, member := range cycle {
+					inCycle[member] = true
+				}
+				c.breakCycle(cycle)
+				break
+			}
+			position[parentID] = len(path)
+			path = append(path, parentID)
+			current = parentID
+		}
+	}
+}
+
+func (c *integrityChecker) breakCycle(cycle []uuid.UUID) {
+	sort.Slice(cycle, func(i, j int) bool { return cycle[i].String() < cycle[j].String() })
+	breakAt := cycle[0]
+
+	members := make([]string, 0, len(cycle))
+	for _, member := range cycle {
+		members = append(members, member.String())
+	}
+
+	c.addIssue(IntegrityCategoryCycle, breakAt, c.parents[breakAt],
+		fmt.Sprintf("parent cycle through %s", strings.Join(members, ", ")))
+	delete(c.parents, breakAt)
+}
+
+// planHierarchy computes the repaired hierarchy fields and plans a fix for every field that differs
+func (c *integrityChecker) planHierarchy() {
+	// Content keeps the existing order of valid children, followed by children missing from it
+	content := make(map[uuid.UUID][]uuid.UUID, len(c.tree.order))
+	for _, id := range c.tree.order {
+		for _, childID := range c.tree.blocks[id].Content {
+			if parentID, ok := c.parents[childID]; ok && parentID == id && !containsID(content[id], childID) {
+				content[id] = append(content[id], childID)
+			}
+		}
+	}
+	for _, id := range c.tree.order {
+		if parentID, ok := c.parents[id]; ok && !containsID(content[parentID], id) {
+			content[parentID] = append(content[parentID], id)
+		}
+	}
+
+	for _, id := range c.tree.order {
+		b := c.tree.blocks[id]
+
+		var parentID *uuid.UUID
+		if p, ok := c.parents[id]; ok {
+			parentID = &p
+		}
+		if !equalIDPointers(b.ParentID, parentID) {
+			c.addRepair(id, BlockPropertyParentID, parentID)
+		}
+
+		newContent := content[id]
+		if newContent == nil {
+			newContent = []uuid.UUID{}
+		}
+		if !equalIDs(b.Content, newContent) {
+			c.addRepair(id, BlockPropertyContent, newContent)
+		}
+
+		descendants := collectDescendantIDs(id, content)
+		if !sameIDSet(b.ChildrenRecursive, descendants) {
+			c.addIssue(IntegrityCategoryStaleChildrenRecursive, id, uuid.Nil,
+				fmt.Sprintf("children_recursive has %d entries, expected %d descendants", len(b.ChildrenRecursive), len(descendants)))
+			c.addRepair(id, BlockPropertyChildrenRecursive, descendants)
+		}
+
+		rootID := c.rootOf(id)
+		if !equalIDPointers(b.RootParentID, rootID) {
+			c.addIssue(IntegrityCategoryWrongRootParent, id, derefID(rootID),
+				fmt.Sprintf("root_parent_id is %s, expected %s", formatIDPointer(b.RootParentID), formatIDPointer(rootID)))
+			c.addRepair(id, BlockPropertyRootParentID, rootID)
+		}
+	}
+}
+
+func (c *integrityChecker) addRepair(blockID uuid.UUID, field string, value interface{}) {
+	c.report.Repairs = append(c.report.Repairs, RepairFix{BlockID: blockID, Field: field, Value: value})
+}
+
+// rootOf returns the topmost repaired ancestor of the block, or nil for roots
+func (c *integrityChecker) rootOf(id uuid.UUID) *uuid.UUID {
+	current := id
+	for {
+		parentID, ok := c.parents[current]
+		if !ok {
+			break
+		}
+		current = parentID
+	}
+	if current == id {
+		return nil
+	}
+	return &current
+}
+
+// collectDescendantIDs walks the content index depth-first
+func collectDescendantIDs(id uuid.UUID, content map[uuid.UUID][]uuid.UUID) []uuid.UUID {
+	descendants := make([]uuid.UUID, 0)
+	visited := map[uuid.UUID]bool{id: true}
+
+	var walk func(parentID uuid.UUID)
+	walk = func(parentID uuid.UUID) {
+		for _, childID := range content[parentID] {
+			if visited[childID] {
+				continue
+			}
+			visited[childID] = true
+			descendants = append(descendants, childID)
+			walk(childID)
+		}
+	}
+	walk(id)
+
+	return descendants
+}
+
+func equalIDs(a, b []uuid.UUID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sameIDSet(a, b []uuid.UUID) bool {
+	setA := make(map[uuid.UUID]bool, len(a))
+	for _, id := range a {
+		setA[id] = true
+	}
+	setB := make(map[uuid.UUID]bool, len(b))
+	for _, id := range b {
+		if !setA[id] {
+			return false
+		}
+		setB[id] = true
+	}
+	return len(setA) == len(setB)
+}
+
+func equalIDPointers(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func copyIDPointer(id *uuid.UUID) *uuid.UUID {
+	if id == nil {
+		return nil
+	}
+	copied := *id
+	return &copied
+}
+
+func derefID(id *uuid.UUID) uuid.UUID {
+	if id == nil {
+		return uuid.Nil
+	}
+	return *id
+}
+
+func formatIDPointer(id *uuid.UUID) string {
+	if id == nil {
+		return "nil"
+	}
+	return id.String()
+}
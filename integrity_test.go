@@ -0,0 +1,107 @@
+package blocks
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckIntegrity(t *testing.T) {
+	t.Run("consistent tree", func(t *testing.T) {
+		page, a, a1, a2, b := newTestTreeBlocks()
+
+		report, err := CheckIntegrity([]Block{page, a, a1, a2, b})
+		assert.NoError(t, err)
+		assert.False(t, report.HasIssues())
+		assert.Empty(t, report.Repairs)
+	})
+
+	t.Run("duplicate ids", func(t *testing.T) {
+		page, _, _, _, _ := newTestTreeBlocks()
+
+		_, err := CheckIntegrity([]Block{page, page})
+		assert.ErrorIs(t, err, ErrDuplicateBlockID)
+	})
+
+	t.Run("reports and repairs every category", func(t *testing.T) {
+		page, a, a1, a2, b := newTestTreeBlocks()
+
+		missing := uuid.New()
+		orphan := NewEmptyBlock()
+		orphan.ParentID = &missing
+		orphan.RootParentID = &missing
+
+		// a2 is also listed under b, and a1 is missing from a
+		b.Content = []uuid.UUID{a2.ID, missing}
+		a.Content = []uuid.UUID{a2.ID, a2.ID}
+		// a1 has a stale root after a move
+		a1.RootParentID = &a.ID
+
+		x, y := NewEmptyBlock(), NewEmptyBlock()
+		x.ParentID, y.ParentID = &y.ID, &x.ID
+		x.Content, y.Content = []uuid.UUID{y.ID}, []uuid.UUID{x.ID}
+		x.ChildrenRecursive, y.ChildrenRecursive = []uuid.UUID{y.ID}, []uuid.UUID{x.ID}
+
+		blocks := []Block{page, a, a1, a2, b, orphan, x, y}
+		report, err := CheckIntegrity(blocks)
+		assert.NoError(t, err)
+
+		grouped := report.IssuesByCategory()
+		assert.Len(t, grouped[IntegrityCategoryOrphan], 1)
+		assert.Equal(t, orphan.ID, grouped[IntegrityCategoryOrphan][0].BlockID)
+		assert.Len(t, grouped[IntegrityCategoryMissingChild], 1)
+		assert.Len(t, grouped[IntegrityCategoryDuplicateChild], 1)
+		assert.Len(t, grouped[IntegrityCategoryParentMismatch], 1)
+		assert.Equal(t, b.ID, grouped[IntegrityCategoryParentMismatch][0].BlockID)
+		assert.Len(t, grouped[IntegrityCategoryMissingFromContent], 1)
+		assert.Equal(t, a1.ID, grouped[IntegrityCategoryMissingFromContent][0].BlockID)
+		assert.Len(t, grouped[IntegrityCategoryCycle], 1)
+		assert.NotEmpty(t, grouped[IntegrityCategoryWrongRootParent])
+		assert.NotEmpty(t, grouped[IntegrityCategoryStaleChildrenRecursive])
+
+		changed, err := ApplyRepairs(blocks, report.Repairs)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, changed)
+
+		repaired := make([]Block, 0, len(blocks))
+		for _, original := range blocks {
+			replacement := original
+			for _, c := range changed {
+				if c.ID == original.ID {
+					replacement = c
+				}
+			}
+			repaired = append(repaired, replacement)
+		}
+
+		report, err = CheckIntegrity(repaired)
+		assert.NoError(t, err)
+		assert.False(t, report.HasIssues(), "%v", report.Issues)
+
+		tree, err := NewBlockTree(repaired)
+		assert.NoError(t, err)
+		assert.NoError(t, tree.Verify())
+		assert.Equal(t, []uuid.UUID{a2.ID, a1.ID}, blockIDs(tree.Children(a.ID)))
+		assert.Empty(t, tree.Children(b.ID))
+
+		root, _ := tree.Root(a1.ID)
+		assert.Equal(t, page.ID, root.ID)
+		repairedOrphan, _ := tree.Get(orphan.ID)
+		assert.Nil(t, repairedOrphan.ParentID)
+		assert.Nil(t, repairedOrphan.RootParentID)
+	})
+
+	t.Run("apply rejects unknown blocks and values", func(t *testing.T) {
+		page, _, _, _, _ := newTestTreeBlocks()
+
+		_, err := ApplyRepairs([]Block{page}, []RepairFix{{BlockID: uuid.New(), Field: BlockPropertyContent, Value: []uuid.UUID{}}})
+		assert.ErrorIs(t, err, ErrBlockNotFound)
+
+		_, err = ApplyRepairs([]Block{page}, []RepairFix{{BlockID: page.ID, Field: BlockPropertyContent, Value: "nope"}})
+		assert.Error(t, err)
+
+		_, err = ApplyRepairs([]Block{page}, []RepairFix{{BlockID: page.ID, Field: BlockPropertyType, Value: nil}})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,215 @@
+package blocks
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultKeywordHalfLife is the age at which the weight of a move keyword is halved
+const DefaultKeywordHalfLife = 30 * 24 * time.Hour
+
+// ScoredKeyword is a keyword with its relevance for a block
+type ScoredKeyword struct {
+	Keyword string  `json:"keyword"`
+	Score   float64 `json:"score"`
+}
+
+// KeywordOptions configures RankedKeywords.
+// Every source is scaled to a maximum of 1 and then multiplied by its weight; a zero weight disables the source.
+type KeywordOptions struct {
+	MoveWeight           float64
+	TermWeight           float64
+	ClassificationWeight float64
+
+	// HalfLife is the age at which the weight of a move is halved; zero disables the decay
+	HalfLife time.Duration
+	// Now is the time the age of moves is computed from; zero means now
+	Now time.Time
+	// Corpus provides the document frequencies for TF-IDF; without it every term has the same IDF
+	Corpus *KeywordCorpus
+	// Limit is the maximum number of keywords returned; zero returns all
+	Limit int
+}
+
+// DefaultKeywordOptions returns options weighting moves, terms and classification equally
+func DefaultKeywordOptions() KeywordOptions {
+	return KeywordOptions{
+		MoveWeight:           1,
+		TermWeight:           1,
+		ClassificationWeight: 1,
+		HalfLife:             DefaultKeywordHalfLife,
+	}
+}
+
+// KeywordCorpus holds the document frequencies of the terms of a set of blocks
+type KeywordCorpus struct {
+	documents   int
+	frequencies map[string]int
+}
+
+// NewKeywordCorpus counts in how many of the blocks every term of the rendered properties appears
+func NewKeywordCorpus(ctx context.Context, blocks []Block) *KeywordCorpus {
+	corpus := &KeywordCorpus{frequencies: make(map[string]int)}
+	for _, b := range blocks {
+		corpus.Add(ctx, b)
+	}
+	return corpus
+}
+
+// Add counts the terms of the block in the corpus
+func (c *KeywordCorpus) Add(ctx context.Context, b Block) {
+	c.documents++
+	seen := make(map[string]bool)
+	for _, term := range keywordTerms(ctx, b) {
+		if !seen[term] {
+			seen[term] = true
+			c.frequencies[term]++
+		}
+	}
+}
+
+// Len returns the number of blocks in the corpus
+func (c *KeywordCorpus) Len() int {
+	return c.documents
+}
+
+// IDF returns the smoothed inverse document frequency of the term
+func (c *KeywordCorpus) IDF(term string) float64 {
+	return math.Log(float64(1+c.documents)/float64(1+c.frequencies[term])) + 1
+}
+
+// RankedKeywords returns the keywords of the block ordered by descending score, ties ordered alphabetically.
+// Scores combine the move keywords weighted by Accuracy and recency, the TF-IDF of the terms of the rendered
+// properties and the Classification labels. Keywords are lower-cased and their whitespace is collapsed, so
+// they can differ from the keywords returned by Keywords.
+func (b *Block) RankedKeywords(ctx context.Context, options KeywordOptions) []ScoredKeyword {
+	scores := make(map[string]float64)
+	addScaled := func(source map[string]float64, weight float64) {
+		if weight <= 0 {
+			return
+		}
+		var highest float64
+		for _, score := range source {
+			highest = math.Max(highest, score)
+		}
+		if highest <= 0 {
+			return
+		}
+		for keyword, score := range source {
+			if score > 0 {
+				scores[keyword] += weight * score / highest
+			}
+		}
+	}
+
+	addScaled(b.moveKeywordScores(options), options.MoveWeight)
+	addScaled(termKeywordScores(ctx, *b, options.Corpus), options.TermWeight)
+
+	labels := make(map[string]float64, len(b.Classification))
+	for class, score := range b.Classification {
+		if keyword := normalizeKeyword(class); keyword != "" {
+			labels[keyword] = math.Max(labels[keyword], score)
+		}
+	}
+	addScaled(labels, options.ClassificationWeight)
+
+	ranked := make([]ScoredKeyword, 0, len(scores))
+	for keyword, score := range scores {
+		ranked = append(ranked, ScoredKeyword{Keyword: keyword, Score: score})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].Keyword < ranked[j].Keyword
+	})
+
+	if options.Limit > 0 && len(ranked) > options.Limit {
+		ranked = ranked[:options.Limit]
+	}
+	return ranked
+}
+
+// moveKeywordScores sums the weight of every move a keyword appears in.
+// A move weighs its Accuracy, or 1 for manual moves without one, halved every HalfLife.
+func (b *Block) moveKeywordScores(options KeywordOptions) map[string]float64 {
+	now := options.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	scores := make(map[string]float64)
+	for _, move := range b.MovesHistory {
+		weight := move.Accuracy
+		if weight <= 0 && move.Reason == MoveReasonManual {
+			weight = 1
+		}
+		if options.HalfLife > 0 {
+			if age := now.Sub(move.Timestamp); age > 0 {
+				weight *= math.Pow(0.5, float64(age)/float64(options.HalfLife))
+			}
+		}
+
+		seen := make(map[string]bool)
+		for _, keyword := range append(append([]string{}, move.SpaceKeywords...), move.ReasoningKeywords...) {
+			keyword = normalizeKeyword(keyword)
+			if keyword == "" || seen[keyword] {
+				continue
+			}
+			seen[keyword] = true
+			scores[keyword] += weight
+		}
+	}
+	return scores
+}
+
+// termKeywordScores returns the TF-IDF of the terms of the rendered properties
+func termKeywordScores(ctx context.Context, b Block, corpus *KeywordCorpus) map[string]float64 {
+	terms := keywordTerms(ctx, b)
+
+	scores := make(map[string]float64)
+	for _, term := range terms {
+		scores[term]++
+	}
+	for term, count := range scores {
+		idf := 1.0
+		if corpus != nil {
+			idf = corpus.IDF(term)
+		}
+		scores[term] = count / float64(len(terms)) * idf
+	}
+	return scores
+}
+
+// keywordTerms returns the words of the rendered properties, without stop words and numbers
+func keywordTerms(ctx context.Context, b Block) []string {
+	var terms []string
+	for _, token := range tokenizeClassificationText(RenderProperties(ctx, b)) {
+		if keywordStopWords[token] || strings.Trim(token, "0123456789") == "" {
+			continue
+		}
+		terms = append(terms, token)
+	}
+	return terms
+}
+
+// normalizeKeyword lower cases the keyword and collapses its whitespace
+func normalizeKeyword(keyword string) string {
+	return strings.Join(strings.Fields(strings.ToLower(keyword)), " ")
+}
+
+// keywordStopWords are common English words that are never keywords
+var keywordStopWords = map[string]bool{
+	"a": true, "about": true, "after": true, "all": true, "also": true, "an": true, "and": true, "any": true,
+	"are": true, "as": true, "at": true, "be": true, "been": true, "but": true, "by": true, "can": true,
+	"could": true, "do": true, "for": true, "from": true, "had": true, "has": true, "have": true, "he": true,
+	"her": true, "his": true, "how": true, "if": true, "in": true, "into": true, "is": true, "it": true,
+	"its": true, "just": true, "more": true, "my": true, "no": true, "not": true, "of": true, "on": true,
+	"or": true, "our": true, "she": true, "so": true, "some": true, "than": true, "that": true, "the": true,
+	"their": true, "them": true, "then": true, "there": true, "these": true, "they": true, "this": true,
+	"to": true, "up": true, "was": true, "we": true, "were": true, "what": true, "when": true, "which": true,
+	"who": true, "will": true, "with": true, "would": true, "you": true, "your": true,
+}
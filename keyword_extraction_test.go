@@ -0,0 +1,100 @@
+package blocks
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRankedKeywords(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	paragraph := func(title string) Block {
+		b := NewEmptyBlock()
+		b.Type = TypeParagraph
+		b.Properties[PropertyKeyTitle] = []interface{}{title}
+		return b
+	}
+
+	keywords := func(ranked []ScoredKeyword) []string {
+		var words []string
+		for _, k := range ranked {
+			words = append(words, k.Keyword)
+		}
+		return words
+	}
+
+	t.Run("move keywords weighted by accuracy and recency", func(t *testing.T) {
+		b := NewEmptyBlock()
+		b.MovesHistory = []Move{
+			{Reason: MoveReasonRouter, Accuracy: 0.9, Timestamp: now.Add(-60 * 24 * time.Hour), SpaceKeywords: []string{"Cooking"}},
+			{Reason: MoveReasonRouter, Accuracy: 0.6, Timestamp: now, SpaceKeywords: []string{"baking"}, ReasoningKeywords: []string{"bread"}},
+			{Reason: MoveReasonManual, Timestamp: now, SpaceKeywords: []string{"bread"}},
+		}
+
+		options := KeywordOptions{MoveWeight: 1, HalfLife: DefaultKeywordHalfLife, Now: now}
+		ranked := b.RankedKeywords(ctx, options)
+		assert.Equal(t, []string{"bread", "baking", "cooking"}, keywords(ranked))
+		assert.Equal(t, 1.0, ranked[0].Score)
+		assert.InDelta(t, 0.6/1.6, ranked[1].Score, 1e-9)
+		assert.InDelta(t, 0.9/4/1.6, ranked[2].Score, 1e-9)
+
+		options.HalfLife = 0
+		ranked = b.RankedKeywords(ctx, options)
+		assert.Equal(t, []string{"bread", "cooking", "baking"}, keywords(ranked))
+	})
+
+	t.Run("tf-idf over a corpus", func(t *testing.T) {
+		b := paragraph("The sourdough bread recipe and the bread starter")
+		corpus := NewKeywordCorpus(ctx, []Block{
+			b,
+			paragraph("A pasta recipe"),
+			paragraph("A cake recipe"),
+		})
+		assert.Equal(t, 3, corpus.Len())
+
+		ranked := b.RankedKeywords(ctx, KeywordOptions{TermWeight: 1, Corpus: corpus})
+		assert.Equal(t, []string{"bread", "sourdough", "starter", "recipe"}, keywords(ranked))
+		assert.Equal(t, 1.0, ranked[0].Score)
+		assert.Less(t, ranked[3].Score, ranked[2].Score)
+	})
+
+	t.Run("sources are combined deterministically", func(t *testing.T) {
+		b := paragraph("Bread")
+		b.Classification = Classification{"Recipe": 0.8, "note": 0.2}
+		b.MovesHistory = []Move{{Reason: MoveReasonRouter, Accuracy: 1, Timestamp: now, SpaceKeywords: []string{"bread", "kitchen"}}}
+
+		options := DefaultKeywordOptions()
+		options.Now = now
+		ranked := b.RankedKeywords(ctx, options)
+		assert.Equal(t, []ScoredKeyword{
+			{Keyword: "bread", Score: 2},
+			{Keyword: "kitchen", Score: 1},
+			{Keyword: "recipe", Score: 1},
+			{Keyword: "note", Score: 0.25},
+		}, ranked)
+
+		for i := 0; i < 10; i++ {
+			assert.Equal(t, ranked, b.RankedKeywords(ctx, options))
+		}
+
+		options.Limit = 2
+		assert.Equal(t, []string{"bread", "kitchen"}, keywords(b.RankedKeywords(ctx, options)))
+
+		options = KeywordOptions{ClassificationWeight: 1}
+		assert.Equal(t, []string{"recipe", "note"}, keywords(b.RankedKeywords(ctx, options)))
+	})
+
+	t.Run("keywords are sorted", func(t *testing.T) {
+		b := NewEmptyBlock()
+		b.MovesHistory = []Move{{SpaceKeywords: []string{"zebra", "apple"}, ReasoningKeywords: []string{"mango", "apple"}}}
+		assert.Equal(t, []string{"apple", "mango", "zebra"}, b.Keywords())
+
+		// Keywords keep the recorded spelling
+		b.MovesHistory = []Move{{SpaceKeywords: []string{"Cooking", "cooking"}}}
+		assert.Equal(t, []string{"Cooking", "cooking"}, b.Keywords())
+	})
+}
@@ -0,0 +1,238 @@
+package blocks
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StatusChange records a lifecycle transition of a block
+type StatusChange struct {
+	From LifecycleStatus `json:"from"`
+	To   LifecycleStatus `json:"to"`
+	At   time.Time       `json:"at"`
+}
+
+// LifecycleHook is called when a block leaves or enters a status.
+// Returning an error aborts the transition and leaves the block untouched.
+type LifecycleHook func(b *Block, from, to LifecycleStatus) error
+
+// TransitionError is returned when a block cannot move from one status to another.
+// Err is ErrUnknownLifecycleStatus, ErrIllegalTransition or the error returned by a hook.
+type TransitionError struct {
+	BlockID uuid.UUID       `json:"block_id"`
+	From    LifecycleStatus `json:"from"`
+	To      LifecycleStatus `json:"to"`
+	Err     error           `json:"-"`
+}
+
+func (e TransitionError) Error() string {
+	return fmt.Sprintf("cannot move block %s from %q to %q: %v", e.BlockID, e.From, e.To, e.Err)
+}
+
+func (e TransitionError) Unwrap() error {
+	return e.Err
+}
+
+// LifecycleMachine holds the allowed transitions between lifecycle statuses and the hooks run on them.
+// The empty status is the initial status of new blocks. It is safe for concurrent use.
+type LifecycleMachine struct {
+	mu          sync.RWMutex
+	transitions map[LifecycleStatus]map[LifecycleStatus]bool
+	onEnter     map[LifecycleStatus][]LifecycleHook
+	onLeave     map[LifecycleStatus][]LifecycleHook
+}
+
+// NewLifecycleMachine creates a machine without any transitions
+func NewLifecycleMachine() *LifecycleMachine {
+	return &LifecycleMachine{
+		transitions: make(map[LifecycleStatus]map[LifecycleStatus]bool),
+		onEnter:     make(map[LifecycleStatus][]LifecycleHook),
+		onLeave:     make(map[LifecycleStatus][]LifecycleHook),
+	}
+}
+
+// NewDefaultLifecycleMachine creates a machine with the processing pipeline of blocks:
+// ingestion, pre-processing, enrichment, transformation, routing, processing, post-processing and indexing,
+// with retries from failed statuses, on_hold for failures that need attention, editing and archiving.
+func NewDefaultLifecycleMachine() *LifecycleMachine {
+	m := NewLifecycleMachine()
+
+	m.Allow("", LifecycleStatusIngested, LifecycleStatusCreated)
+	m.Allow(LifecycleStatusIngested, LifecycleStatusCreated, LifecycleStatusPreProcessing, LifecycleStatusEnriching)
+	m.Allow(LifecycleStatusCreated, LifecycleStatusPreProcessing, LifecycleStatusEnriching, LifecycleStatusEditing, LifecycleStatusProcessing)
+
+	m.Allow(LifecycleStatusPreProcessing, LifecycleStatusPreProcessed, LifecycleStatusPreProcessingFailed)
+	m.Allow(LifecycleStatusPreProcessingFailed, LifecycleStatusPreProcessing, LifecycleStatusOnHold)
+	m.Allow(LifecycleStatusPreProcessed, LifecycleStatusEnriching)
+
+	m.Allow(LifecycleStatusEnriching, LifecycleStatusEnriched, LifecycleStatusEnrichmentFailed)
+	m.Allow(LifecycleStatusEnrichmentFailed, LifecycleStatusEnriching, LifecycleStatusOnHold)
+	m.Allow(LifecycleStatusEnriched, LifecycleStatusTransforming, LifecycleStatusRouted, LifecycleStatusRoutingFailed, LifecycleStatusProcessing)
+
+	m.Allow(LifecycleStatusTransforming, LifecycleStatusTransformed, LifecycleStatusOnHold)
+	m.Allow(LifecycleStatusTransformed, LifecycleStatusRouted, LifecycleStatusRoutingFailed, LifecycleStatusProcessing)
+
+	m.Allow(LifecycleStatusRouted, LifecycleStatusRoutedFinal, LifecycleStatusRoutingFailed, LifecycleStatusProcessing, LifecycleStatusEditing)
+	m.Allow(LifecycleStatusRoutingFailed, LifecycleStatusTransformed, LifecycleStatusRouted, LifecycleStatusOnHold)
+	m.Allow(LifecycleStatusRoutedFinal, LifecycleStatusProcessing, LifecycleStatusEditing, LifecycleStatusIndexing)
+
+	m.Allow(LifecycleStatusProcessing, LifecycleStatusProcessed, LifecycleStatusProcessingFailed)
+	m.Allow(LifecycleStatusProcessingFailed, LifecycleStatusProcessing, LifecycleStatusOnHold)
+	m.Allow(LifecycleStatusProcessed, LifecycleStatusPostProcessing, LifecycleStatusIndexing, LifecycleStatusEditing)
+
+	m.Allow(LifecycleStatusPostProcessing, LifecycleStatusPostProcessed, LifecycleStatusPostProcessingFailed)
+	m.Allow(LifecycleStatusPostProcessingFailed, LifecycleStatusPostProcessing, LifecycleStatusOnHold)
+	m.Allow(LifecycleStatusPostProcessed, LifecycleStatusIndexing, LifecycleStatusEditing)
+
+	m.Allow(LifecycleStatusIndexing, LifecycleStatusIndexed, LifecycleStatusOnHold)
+	m.Allow(LifecycleStatusIndexed, LifecycleStatusEditing, LifecycleStatusEnriching, LifecycleStatusIndexing)
+
+	m.Allow(LifecycleStatusEditing, LifecycleStatusEdited)
+	m.Allow(LifecycleStatusEdited, LifecycleStatusEditing, LifecycleStatusEnriching, LifecycleStatusProcessing, LifecycleStatusIndexing)
+
+	m.Allow(LifecycleStatusOnHold, LifecycleStatusPreProcessing, LifecycleStatusEnriching, LifecycleStatusTransforming,
+		LifecycleStatusTransformed, LifecycleStatusRouted, LifecycleStatusProcessing, LifecycleStatusPostProcessing, LifecycleStatusIndexing)
+
+	// Every status can be archived, and archived blocks can be restored
+	for _, status := range m.Statuses() {
+		if status != "" && status != LifecycleStatusArchived {
+			m.Allow(status, LifecycleStatusArchived)
+		}
+	}
+	m.Allow(LifecycleStatusArchived, LifecycleStatusCreated)
+
+	return m
+}
+
+// Allow adds transitions from one status to the given statuses
+func (m *LifecycleMachine) Allow(from LifecycleStatus, to ...LifecycleStatus) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.transitions[from] == nil {
+		m.transitions[from] = make(map[LifecycleStatus]bool)
+	}
+	for _, status := range to {
+		m.transitions[from][status] = true
+		if m.transitions[status] == nil {
+			m.transitions[status] = make(map[LifecycleStatus]bool)
+		}
+	}
+}
+
+// OnEnter registers a hook run after a block enters the status
+func (m *LifecycleMachine) OnEnter(status LifecycleStatus, hook LifecycleHook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onEnter[status] = append(m.onEnter[status], hook)
+}
+
+// OnLeave registers a hook run before a block leaves the status
+func (m *LifecycleMachine) OnLeave(status LifecycleStatus, hook LifecycleHook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onLeave[status] = append(m.onLeave[status], hook)
+}
+
+// Statuses returns all statuses known to the machine, sorted
+func (m *LifecycleMachine) Statuses() []LifecycleStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	statuses := make([]LifecycleStatus, 0, len(m.transitions))
+	for status := range m.transitions {
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i] < statuses[j] })
+	return statuses
+}
+
+// Next returns the statuses a block can move to from the given status, sorted
+func (m *LifecycleMachine) Next(from LifecycleStatus) []LifecycleStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	next := make([]LifecycleStatus, 0, len(m.transitions[from]))
+	for status := range m.transitions[from] {
+		next = append(next, status)
+	}
+	sort.Slice(next, func(i, j int) bool { return next[i] < next[j] })
+	return next
+}
+
+// CanTransition reports whether the machine allows moving from one status to the other
+func (m *LifecycleMachine) CanTransition(from, to LifecycleStatus) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.transitions[from][to]
+}
+
+// Transition moves the block to a new status. Leave hooks of the current status run first, then the
+// status is changed, recorded in StatusHistory if it is recordable, and the enter hooks of the new status run.
+// The block is only modified if the transition is allowed and no hook fails.
+// Moving to the current status does nothing.
+func (m *LifecycleMachine) Transition(b *Block, to LifecycleStatus) error {
+	from := b.LifecycleStatus
+	if from == to {
+		return nil
+	}
+
+	m.mu.RLock()
+	_, fromKnown := m.transitions[from]
+	_, toKnown := m.transitions[to]
+	allowed := m.transitions[from][to]
+	leaveHooks := append([]LifecycleHook{}, m.onLeave[from]...)
+	enterHooks := append([]LifecycleHook{}, m.onEnter[to]...)
+	m.mu.RUnlock()
+
+	transitionErr := TransitionError{BlockID: b.ID, From: from, To: to}
+	switch {
+	case !fromKnown || !toKnown:
+		transitionErr.Err = ErrUnknownLifecycleStatus
+		return transitionErr
+	case !allowed:
+		transitionErr.Err = ErrIllegalTransition
+		return transitionErr
+	}
+
+	updated := b.Copy()
+	for _, hook := range leaveHooks {
+		if err := hook(&updated, from, to); err != nil {
+			transitionErr.Err = err
+			return transitionErr
+		}
+	}
+
+	now := time.Now()
+	updated.LifecycleStatus = to
+	updated.UpdatedAt = now
+	if to.Recordable() {
+		updated.StatusHistory = append(updated.StatusHistory, StatusChange{From: from, To: to, At: now})
+	}
+
+	for _, hook := range enterHooks {
+		if err := hook(&updated, from, to); err != nil {
+			transitionErr.Err = err
+			return transitionErr
+		}
+	}
+
+	*b = updated
+	return nil
+}
+
+var defaultLifecycleMachine = NewDefaultLifecycleMachine()
+
+// DefaultLifecycleMachine returns the machine used by Transition, to register hooks or extra transitions
+func DefaultLifecycleMachine() *LifecycleMachine {
+	return defaultLifecycleMachine
+}
+
+// Transition moves the block to a new status using the default lifecycle machine
+func Transition(b *Block, to LifecycleStatus) error {
+	return defaultLifecycleMachine.Transition(b, to)
+}
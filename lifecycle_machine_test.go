@@ -0,0 +1,92 @@
+package blocks
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransition(t *testing.T) {
+	t.Run("pipeline with status history", func(t *testing.T) {
+		b := NewEmptyBlock()
+
+		for _, status := range []LifecycleStatus{
+			LifecycleStatusIngested,
+			LifecycleStatusEnriching,
+			LifecycleStatusEnrichmentFailed,
+			LifecycleStatusEnriching,
+			LifecycleStatusEnriched,
+			LifecycleStatusRouted,
+		} {
+			assert.NoError(t, Transition(&b, status))
+		}
+
+		assert.Equal(t, LifecycleStatusRouted, b.LifecycleStatus)
+		assert.Len(t, b.StatusHistory, 3)
+		assert.Equal(t, LifecycleStatus(""), b.StatusHistory[0].From)
+		assert.Equal(t, LifecycleStatusIngested, b.StatusHistory[0].To)
+		assert.Equal(t, LifecycleStatusEnriching, b.StatusHistory[1].From)
+		assert.Equal(t, LifecycleStatusEnriched, b.StatusHistory[1].To)
+		assert.Equal(t, LifecycleStatusRouted, b.StatusHistory[2].To)
+		assert.False(t, b.StatusHistory[2].At.IsZero())
+	})
+
+	t.Run("illegal and unknown transitions", func(t *testing.T) {
+		b := NewEmptyBlock()
+		b.LifecycleStatus = LifecycleStatusIngested
+
+		err := Transition(&b, LifecycleStatusIndexed)
+		assert.ErrorIs(t, err, ErrIllegalTransition)
+
+		var transitionErr TransitionError
+		assert.True(t, errors.As(err, &transitionErr))
+		assert.Equal(t, LifecycleStatusIngested, transitionErr.From)
+		assert.Equal(t, LifecycleStatusIndexed, transitionErr.To)
+		assert.Equal(t, LifecycleStatusIngested, b.LifecycleStatus)
+
+		assert.ErrorIs(t, Transition(&b, LifecycleStatus("teleported")), ErrUnknownLifecycleStatus)
+		assert.NoError(t, Transition(&b, LifecycleStatusIngested))
+		assert.Empty(t, b.StatusHistory)
+	})
+
+	t.Run("archive and restore", func(t *testing.T) {
+		machine := NewDefaultLifecycleMachine()
+		for _, status := range machine.Statuses() {
+			if status != "" && status != LifecycleStatusArchived {
+				assert.True(t, machine.CanTransition(status, LifecycleStatusArchived), status)
+			}
+		}
+		assert.Equal(t, []LifecycleStatus{LifecycleStatusCreated}, machine.Next(LifecycleStatusArchived))
+	})
+
+	t.Run("hooks", func(t *testing.T) {
+		machine := NewDefaultLifecycleMachine()
+		var calls []string
+
+		machine.OnLeave(LifecycleStatusCreated, func(b *Block, from, to LifecycleStatus) error {
+			calls = append(calls, "leave "+from.String())
+			return nil
+		})
+		machine.OnEnter(LifecycleStatusEditing, func(b *Block, from, to LifecycleStatus) error {
+			calls = append(calls, "enter "+to.String())
+			b.Meaning = "being edited"
+			return nil
+		})
+		machine.OnEnter(LifecycleStatusEdited, func(b *Block, from, to LifecycleStatus) error {
+			return errors.New("not allowed today")
+		})
+
+		b := NewEmptyBlock()
+		b.LifecycleStatus = LifecycleStatusCreated
+
+		assert.NoError(t, machine.Transition(&b, LifecycleStatusEditing))
+		assert.Equal(t, []string{"leave created", "enter editing"}, calls)
+		assert.Equal(t, "being edited", b.Meaning)
+
+		err := machine.Transition(&b, LifecycleStatusEdited)
+		assert.EqualError(t, err, `cannot move block `+b.ID.String()+` from "editing" to "edited": not allowed today`)
+		assert.Equal(t, LifecycleStatusEditing, b.LifecycleStatus)
+		assert.Empty(t, b.StatusHistory)
+	})
+}
@@ -25,3 +25,12 @@ func RenderLineProperties(b Block) string {
 func GetLineProperties() []string {
 	return []string{}
 }
+
+func init() {
+	mustRegisterType(TypeDefinition{
+		DataType:     TypeLine,
+		RenderFunc:   renderWith(RenderLineProperties),
+		PropertyKeys: GetLineProperties(),
+		Content:      BlockContentTypeTextual,
+	})
+}
@@ -150,3 +150,13 @@ func GetLinkProperties() []string {
 		PropertyKeyEnriched,
 	}
 }
+
+func init() {
+	mustRegisterType(TypeDefinition{
+		DataType:     TypeLink,
+		RenderFunc:   renderWith(RenderLinkProperties),
+		PropertyKeys: GetLinkProperties(),
+		Content:      BlockContentTypeStructural,
+		Schema:       NewPropertySchema(GetLinkProperties()...).Require(PropertyKeyURL),
+	})
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+
+	"github.com/google/uuid"
 )
 
 // NewBlockFromMarkdown creates a new Block from markdown text.
@@ -145,3 +147,390 @@ func matchNumberedList(s string) bool {
 	}
 	return false
 }
+
+var (
+	markdownHeadingPattern  = regexp.MustCompile(`^(#+)\s+(.*)$`)
+	markdownListItemPattern = regexp.MustCompile(`^([-*+]|\d+[.)])\s+(.*)$`)
+	markdownToDoPattern     = regexp.MustCompile(`^\[( |x|X)\]\s*(.*)$`)
+	markdownImagePattern    = regexp.MustCompile(`^!\[(.*?)\]\((.*?)\)$`)
+	markdownRulePattern     = regexp.MustCompile(`^(-{3,}|\*{3,}|_{3,})$`)
+	markdownLinkPattern     = regexp.MustCompile(`^\[(.*?)\]\((.*?)\)$`)
+	markdownURLPattern      = regexp.MustCompile(`^(https?|ftp)://\S+$`)
+)
+
+// markdownNode is a block being built by the document parser together with its children
+type markdownNode struct {
+	block    Block
+	indent   int
+	children []*markdownNode
+}
+
+func (n *markdownNode) appendChild(child *markdownNode) {
+	n.children = append(n.children, child)
+}
+
+// appendTitleLine appends a line to the title of the block, separated by a newline
+func (n *markdownNode) appendTitleLine(line string) {
+	title, _ := n.block.Properties.GetString(PropertyKeyTitle)
+	_ = n.block.Properties.ReplaceValue(PropertyKeyTitle, title+"\n"+line)
+}
+
+// markdownDocumentParser keeps the state needed while parsing a document line by line
+type markdownDocumentParser struct {
+	root *markdownNode
+
+	// listStack holds the open list items, from the outermost to the innermost
+	listStack []*markdownNode
+
+	// openParagraph, openQuote and openItem receive continuation lines
+	openParagraph *markdownNode
+	openQuote     *markdownNode
+	openItem      *markdownNode
+
+	// fence state for fenced code blocks
+	fence         string
+	fenceIndent   int
+	fenceLanguage string
+	fenceLines    []string
+	fenceParent   *markdownNode
+}
+
+// NewBlocksFromMarkdown parses a whole markdown document into a page block and its descendants.
+// The returned slice starts with the page, followed by all descendants in document order.
+// Content, ParentID, RootParentID and ChildrenRecursive are linked for every block.
+// Nested bullet and numbered lists become children of their list item, "- [ ]" and "- [x]"
+// become to-dos, "---" becomes a line, "![alt](url)" becomes an image and fenced code,
+// blockquotes and multi-line paragraphs are kept as single blocks.
+func NewBlocksFromMarkdown(doc string) ([]Block, error) {
+	page := NewEmptyBlock()
+	page.Type = TypePage
+
+	return parseMarkdownDocument(page, strings.Split(strings.ReplaceAll(doc, "\r\n", "\n"), "\n"))
+}
+
+// NewPageFromMarkdown works like NewBlocksFromMarkdown, but a level 1 heading on the first non-empty line
+// becomes the title of the page instead of a header block. It parses pages exported with RenderMarkdown.
+func NewPageFromMarkdown(doc string) ([]Block, error) {
+	page := NewEmptyBlock()
+	page.Type = TypePage
+
+	lines := strings.Split(strings.ReplaceAll(doc, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if matches := markdownHeadingPattern.FindStringSubmatch(line); matches != nil && len(matches[1]) == 1 {
+			if err := page.Properties.ReplaceValue(PropertyKeyTitle, strings.TrimSpace(matches[2])); err != nil {
+				return nil, fmt.Errorf("failed to set page title: %w", err)
+			}
+			lines = lines[i+1:]
+		}
+		break
+	}
+
+	return parseMarkdownDocument(page, lines)
+}
+
+// parseMarkdownDocument parses the lines into the children of the page
+func parseMarkdownDocument(page Block, lines []string) ([]Block, error) {
+	parser := &markdownDocumentParser{root: &markdownNode{block: page, indent: -1}}
+
+	for _, line := range lines {
+		if err := parser.parseLine(line); err != nil {
+			return nil, err
+		}
+	}
+
+	if parser.fence != "" {
+		// Unterminated fence, keep what we have
+		if err := parser.closeFence(); err != nil {
+			return nil, err
+		}
+	}
+
+	return linkMarkdownTree(parser.root), nil
+}
+
+func (p *markdownDocumentParser) parseLine(line string) error {
+	indent := markdownIndent(line)
+	trimmed := strings.TrimSpace(line)
+
+	if p.fence != "" {
+		if strings.HasPrefix(trimmed, p.fence) && strings.Trim(trimmed, p.fence[:1]) == "" {
+			return p.closeFence()
+		}
+		p.fenceLines = append(p.fenceLines, trimIndent(line, p.fenceIndent))
+		return nil
+	}
+
+	if trimmed == "" {
+		p.closeOpenBlocks()
+		return nil
+	}
+
+	if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+		p.closeOpenBlocks()
+		marker := trimmed[:3]
+		for len(marker) < len(trimmed) && trimmed[len(marker)] == marker[0] {
+			marker += marker[:1]
+		}
+		p.fence = marker
+		p.fenceIndent = indent
+		p.fenceLanguage = strings.TrimSpace(strings.TrimPrefix(trimmed, marker))
+		p.fenceLines = nil
+		p.fenceParent = p.containerFor(indent)
+		return nil
+	}
+
+	if markdownRulePattern.MatchString(strings.ReplaceAll(trimmed, " ", "")) {
+		p.closeOpenBlocks()
+		block := NewEmptyBlock()
+		block.Type = TypeLine
+		if err := AddLineProperties(&block); err != nil {
+			return fmt.Errorf("failed to add line properties: %w", err)
+		}
+		p.containerFor(indent).appendChild(&markdownNode{block: block, indent: indent})
+		return nil
+	}
+
+	if strings.HasPrefix(trimmed, ">") {
+		text := strings.TrimPrefix(strings.TrimPrefix(trimmed, ">"), " ")
+		if p.openQuote != nil {
+			p.openQuote.appendTitleLine(text)
+			return nil
+		}
+		p.closeOpenBlocks()
+		block := NewEmptyBlock()
+		block.Type = TypeQuote
+		if err := AddQuoteProperties(&block, &text); err != nil {
+			return fmt.Errorf("failed to add quote properties: %w", err)
+		}
+		p.openQuote = &markdownNode{block: block, indent: indent}
+		p.containerFor(indent).appendChild(p.openQuote)
+		return nil
+	}
+
+	if matches := markdownHeadingPattern.FindStringSubmatch(trimmed); matches != nil {
+		// Lines starting with more than six # are paragraph text
+		if headingType, ok := markdownHeadingType(len(matches[1])); ok {
+			p.closeOpenBlocks()
+			block := NewEmptyBlock()
+			block.Type = headingType
+			title := strings.TrimSpace(matches[2])
+			if err := AddHeaderProperties(&block, &title); err != nil {
+				return fmt.Errorf("failed to add header properties: %w", err)
+			}
+			p.containerFor(indent).appendChild(&markdownNode{block: block, indent: indent})
+			return nil
+		}
+	}
+
+	if matches := markdownListItemPattern.FindStringSubmatch(trimmed); matches != nil {
+		p.closeOpenBlocks()
+		block, err := newMarkdownListItem(matches[1], matches[2])
+		if err != nil {
+			return err
+		}
+		node := &markdownNode{block: block, indent: indent}
+		p.containerFor(indent).appendChild(node)
+		p.listStack = append(p.listStack, node)
+		p.openItem = node
+		return nil
+	}
+
+	if matches := markdownImagePattern.FindStringSubmatch(trimmed); matches != nil {
+		p.closeOpenBlocks()
+		block := NewEmptyBlock()
+		block.Type = TypeImage
+		url := matches[2]
+		if err := AddImageProperties(&block, nil, nil, &url, nil, nil, nil, false); err != nil {
+			return fmt.Errorf("failed to add image properties: %w", err)
+		}
+		if alt := matches[1]; alt != "" {
+			if err := block.Properties.ReplaceValue(PropertyKeyDescription, alt); err != nil {
+				return fmt.Errorf("failed to set description property: %w", err)
+			}
+		}
+		p.containerFor(indent).appendChild(&markdownNode{block: block, indent: indent})
+		return nil
+	}
+
+	// Lazy continuation lines of paragraphs and list items
+	if p.openParagraph != nil {
+		p.openParagraph.appendTitleLine(trimmed)
+		return nil
+	}
+	if p.openItem != nil && indent > p.openItem.indent {
+		p.openItem.appendTitleLine(trimmed)
+		return nil
+	}
+	p.closeOpenBlocks()
+
+	// A line holding a single link or URL becomes a link block
+	if markdownLinkPattern.MatchString(trimmed) || markdownURLPattern.MatchString(trimmed) {
+		block, err := NewBlockFromMarkdown(trimmed)
+		if err != nil {
+			return err
+		}
+		p.containerFor(indent).appendChild(&markdownNode{block: block, indent: indent})
+		return nil
+	}
+
+	block := NewEmptyBlock()
+	block.Type = TypeParagraph
+	if err := AddParagraphProperties(&block, &trimmed); err != nil {
+		return fmt.Errorf("failed to add paragraph properties: %w", err)
+	}
+	p.openParagraph = &markdownNode{block: block, indent: indent}
+	p.containerFor(indent).appendChild(p.openParagraph)
+	return nil
+}
+
+// markdownHeadingType returns the header type for the number of # of a heading
+func markdownHeadingType(level int) (DataType, bool) {
+	switch level {
+	case 1:
+		return TypeHeader1, true
+	case 2:
+		return TypeHeader2, true
+	case 3:
+		return TypeHeader3, true
+	case 4:
+		return TypeHeader4, true
+	case 5:
+		return TypeHeader5, true
+	case 6:
+		return TypeHeader6, true
+	default:
+		return "", false
+	}
+}
+
+// closeOpenBlocks stops collecting continuation lines
+func (p *markdownDocumentParser) closeOpenBlocks() {
+	p.openParagraph = nil
+	p.openQuote = nil
+	p.openItem = nil
+}
+
+func (p *markdownDocumentParser) closeFence() error {
+	block := NewEmptyBlock()
+	block.Type = TypeCode
+	code := strings.Join(p.fenceLines, "\n")
+	if err := AddCodeProperties(&block, &code, &p.fenceLanguage); err != nil {
+		return fmt.Errorf("failed to add code properties: %w", err)
+	}
+	p.fenceParent.appendChild(&markdownNode{block: block, indent: p.fenceIndent})
+
+	p.fence = ""
+	p.fenceLines = nil
+	p.fenceParent = nil
+	return nil
+}
+
+// containerFor returns the node a line with the given indentation belongs to:
+// the innermost open list item indented less than the line, or the page.
+func (p *markdownDocumentParser) containerFor(indent int) *markdownNode {
+	for len(p.listStack) > 0 && p.listStack[len(p.listStack)-1].indent >= indent {
+		p.listStack = p.listStack[:len(p.listStack)-1]
+	}
+	if len(p.listStack) == 0 {
+		return p.root
+	}
+	return p.listStack[len(p.listStack)-1]
+}
+
+// newMarkdownListItem creates a bullet, numbered or to-do block from a list marker and its text
+func newMarkdownListItem(marker string, text string) (Block, error) {
+	block := NewEmptyBlock()
+
+	if marker[0] >= '0' && marker[0] <= '9' {
+		block.Type = TypeNumberedListItem
+		if err := AddNumberedListItemProperties(&block, &text); err != nil {
+			return block, fmt.Errorf("failed to add numbered list item properties: %w", err)
+		}
+		return block, nil
+	}
+
+	if matches := markdownToDoPattern.FindStringSubmatch(text); matches != nil {
+		block.Type = TypeToDo
+		title := matches[2]
+		checked := matches[1] != " "
+		if err := AddToDoProperties(&block, &title, &checked, nil, nil); err != nil {
+			return block, fmt.Errorf("failed to add to-do properties: %w", err)
+		}
+		return block, nil
+	}
+
+	block.Type = TypeBulletListItem
+	if err := AddBulletListItemProperties(&block, &text); err != nil {
+		return block, fmt.Errorf("failed to add bullet list item properties: %w", err)
+	}
+	return block, nil
+}
+
+// linkMarkdownTree sets the hierarchy fields of all parsed blocks and flattens them in document order
+func linkMarkdownTree(root *markdownNode) []Block {
+	rootID := root.block.ID
+
+	var result []Block
+	var visit func(node *markdownNode, parentID *uuid.UUID) []uuid.UUID
+	visit = func(node *markdownNode, parentID *uuid.UUID) []uuid.UUID {
+		position := len(result)
+		result = append(result, Block{})
+
+		block := node.block
+		if parentID != nil {
+			pID := *parentID
+			rID := rootID
+			block.ParentID = &pID
+			block.RootParentID = &rID
+		}
+
+		block.Content = make([]uuid.UUID, 0, len(node.children))
+		block.ChildrenRecursive = make([]uuid.UUID, 0)
+		for _, child := range node.children {
+			block.Content = append(block.Content, child.block.ID)
+			block.ChildrenRecursive = append(block.ChildrenRecursive, child.block.ID)
+			block.ChildrenRecursive = append(block.ChildrenRecursive, visit(child, &block.ID)...)
+		}
+
+		result[position] = block
+		return block.ChildrenRecursive
+	}
+	visit(root, nil)
+
+	return result
+}
+
+// markdownIndent returns the width of the leading whitespace of a line, counting tabs as 4 spaces
+func markdownIndent(line string) int {
+	width := 0
+	for _, c := range line {
+		switch c {
+		case ' ':
+			width++
+		case '\t':
+			width += 4
+		default:
+			return width
+		}
+	}
+	return width
+}
+
+// trimIndent removes up to width columns of leading whitespace from a line
+func trimIndent(line string, width int) string {
+	for width > 0 && len(line) > 0 {
+		switch line[0] {
+		case ' ':
+			width--
+		case '\t':
+			width -= 4
+		default:
+			return line
+		}
+		line = line[1:]
+	}
+	return line
+}
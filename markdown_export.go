@@ -0,0 +1,176 @@
+package blocks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// RenderMarkdown renders a block and all of its descendants as a markdown document.
+// Consecutive numbered list items are numbered, children of list items and to-dos are
+// indented under their item and block-level elements are separated by blank lines.
+// The output can be parsed back with NewBlocksFromMarkdown, or with NewPageFromMarkdown when a page is rendered.
+func RenderMarkdown(ctx context.Context, b Block, lookupBlocks map[uuid.UUID]Block) (string, error) {
+	return renderMarkdownSequence(ctx, []Block{b}, lookupBlocks, make(map[uuid.UUID]bool))
+}
+
+// renderMarkdownSequence renders sibling blocks, keeping track of list numbering and separators
+func renderMarkdownSequence(ctx context.Context, siblings []Block, lookupBlocks map[uuid.UUID]Block, visitedBlocks map[uuid.UUID]bool) (string, error) {
+	var sb strings.Builder
+	var previous *Block
+	var previousRendered string
+	number := 0
+
+	for i := range siblings {
+		block := siblings[i]
+
+		// Skip blocks we have already rendered to prevent cycles
+		if visitedBlocks[block.ID] {
+			continue
+		}
+		visitedBlocks[block.ID] = true
+
+		if block.Type == TypeNumberedListItem {
+			if previous != nil && previous.Type == TypeNumberedListItem {
+				number++
+			} else {
+				number = 1
+			}
+		}
+
+		rendered, err := renderMarkdownBlock(ctx, block, number, lookupBlocks, visitedBlocks)
+		if err != nil {
+			return "", err
+		}
+		if rendered == "" {
+			continue
+		}
+
+		if sb.Len() > 0 {
+			if previous != nil && isMarkdownListType(previous.Type) && isMarkdownListType(block.Type) &&
+				!strings.Contains(previousRendered, "\n\n") {
+				// Items of the same list are not separated by blank lines, unless the previous item is loose
+				sb.WriteString("\n")
+			} else {
+				sb.WriteString("\n\n")
+			}
+		}
+		sb.WriteString(rendered)
+		previous = &siblings[i]
+		previousRendered = rendered
+	}
+
+	return sb.String(), nil
+}
+
+// renderMarkdownBlock renders a single block followed by its children
+func renderMarkdownBlock(ctx context.Context, b Block, number int, lookupBlocks map[uuid.UUID]Block, visitedBlocks map[uuid.UUID]bool) (string, error) {
+	own := renderMarkdownProperties(ctx, b, number)
+
+	children := make([]Block, 0, len(b.Content))
+	for _, childID := range b.Content {
+		if child, exists := lookupBlocks[childID]; exists {
+			children = append(children, child)
+		}
+	}
+
+	renderedChildren, err := renderMarkdownSequence(ctx, children, lookupBlocks, visitedBlocks)
+	if err != nil {
+		return "", err
+	}
+
+	if !isMarkdownListType(b.Type) {
+		// Children of other blocks follow them as separate block-level elements
+		switch {
+		case own == "":
+			return renderedChildren, nil
+		case renderedChildren == "":
+			return own, nil
+		default:
+			return own + "\n\n" + renderedChildren, nil
+		}
+	}
+
+	// Continuation lines and children of list items are indented under the item text
+	width := markdownListMarkerWidth(b.Type, number)
+	own = indentMarkdownLines(own, width, true)
+	if renderedChildren == "" {
+		return own, nil
+	}
+
+	separator := "\n\n"
+	if first := firstRenderedChild(children, visitedBlocks); first != nil && isMarkdownListType(first.Type) {
+		separator = "\n"
+	}
+	return own + separator + indentMarkdownLines(renderedChildren, width, false), nil
+}
+
+// renderMarkdownProperties renders the block itself, without its children
+func renderMarkdownProperties(ctx context.Context, b Block, number int) string {
+	switch b.Type {
+	case TypePage:
+		if title := RenderPageProperties(b); title != "" {
+			return "# " + title
+		}
+		return ""
+	case TypeNumberedListItem:
+		return fmt.Sprintf("%d. %s", number, RenderNumberedListItemProperties(b))
+	case TypeBulletListItem:
+		return "- " + RenderBulletListItemProperties(b)
+	case TypeImage:
+		publicURL, ok := b.Properties.GetString(PropertyKeyPublicURL)
+		if !ok || publicURL == "" {
+			return RenderImageProperties(b)
+		}
+		alt, _ := b.Properties.GetString(PropertyKeyDescription)
+		return fmt.Sprintf("![%s](%s)", alt, publicURL)
+	case TypeLink:
+		url, _ := b.Properties.GetString(PropertyKeyURL)
+		title, _ := b.Properties.GetString(PropertyKeyTitle)
+		description, _ := b.Properties.GetString(PropertyKeyDescription)
+		if url != "" && title == url && description == "" {
+			// Bare URLs stay bare
+			return url
+		}
+		return RenderLinkProperties(b)
+	default:
+		return RenderProperties(ctx, b)
+	}
+}
+
+// firstRenderedChild returns the first child that was rendered in this pass
+func firstRenderedChild(children []Block, visitedBlocks map[uuid.UUID]bool) *Block {
+	for i := range children {
+		if visitedBlocks[children[i].ID] {
+			return &children[i]
+		}
+	}
+	return nil
+}
+
+func isMarkdownListType(t DataType) bool {
+	return t == TypeBulletListItem || t == TypeNumberedListItem || t == TypeToDo
+}
+
+// markdownListMarkerWidth returns the width of the list marker, which is the indentation of nested content
+func markdownListMarkerWidth(t DataType, number int) int {
+	if t == TypeNumberedListItem {
+		return len(fmt.Sprintf("%d. ", number))
+	}
+	return 2
+}
+
+// indentMarkdownLines indents every non-empty line by width spaces, optionally keeping the first line as is
+func indentMarkdownLines(text string, width int, skipFirst bool) string {
+	prefix := strings.Repeat(" ", width)
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line == "" || (skipFirst && i == 0) {
+			continue
+		}
+		lines[i] = prefix + line
+	}
+	return strings.Join(lines, "\n")
+}
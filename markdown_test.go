@@ -1,8 +1,11 @@
 package blocks
 
 import (
+	"context"
 	"testing"
 
+	"github.com/google/uuid"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -330,3 +333,234 @@ func TestMatchNumberedList(t *testing.T) {
 		})
 	}
 }
+
+func TestNewBlocksFromMarkdown(t *testing.T) {
+	doc := `# Shopping
+
+Things to buy
+before the weekend
+
+- Fruit
+  - Apples
+    - [x] Granny Smith
+  - [ ] Pears
+- Bread
+
+1. First
+2. Second
+   1. Nested
+
+---
+
+![A cat](https://example.com/cat.png)
+
+` + "```go\nfunc main() {\n\n}\n```" + `
+
+> To be
+> or not to be
+
+https://example.com`
+
+	result, err := NewBlocksFromMarkdown(doc)
+	assert.NoError(t, err)
+
+	byID := make(map[uuid.UUID]Block, len(result))
+	for _, b := range result {
+		byID[b.ID] = b
+	}
+	children := func(b Block) []Block {
+		var blocks []Block
+		for _, id := range b.Content {
+			blocks = append(blocks, byID[id])
+		}
+		return blocks
+	}
+	title := func(b Block) string {
+		title, _ := b.Properties.GetString(PropertyKeyTitle)
+		return title
+	}
+
+	page := result[0]
+	assert.Equal(t, TypePage, page.Type)
+	assert.Nil(t, page.ParentID)
+	assert.Nil(t, page.RootParentID)
+	assert.Len(t, page.ChildrenRecursive, len(result)-1)
+
+	top := children(page)
+	var types []DataType
+	for _, b := range top {
+		types = append(types, b.Type)
+	}
+	assert.Equal(t, []DataType{
+		TypeHeader1, TypeParagraph, TypeBulletListItem, TypeBulletListItem,
+		TypeNumberedListItem, TypeNumberedListItem, TypeLine, TypeImage, TypeCode, TypeQuote, TypeLink,
+	}, types)
+
+	assert.Equal(t, "Shopping", title(top[0]))
+	assert.Equal(t, "Things to buy\nbefore the weekend", title(top[1]))
+
+	// Nested lists
+	fruit := top[2]
+	assert.Equal(t, "Fruit", title(fruit))
+	fruitChildren := children(fruit)
+	assert.Len(t, fruitChildren, 2)
+	assert.Equal(t, "Apples", title(fruitChildren[0]))
+	assert.Equal(t, TypeToDo, fruitChildren[1].Type)
+	checked, _ := fruitChildren[1].Properties.GetBool(PropertyKeyChecked)
+	assert.False(t, checked)
+
+	grannySmith := children(fruitChildren[0])[0]
+	assert.Equal(t, TypeToDo, grannySmith.Type)
+	assert.Equal(t, "Granny Smith", title(grannySmith))
+	checked, _ = grannySmith.Properties.GetBool(PropertyKeyChecked)
+	assert.True(t, checked)
+	assert.Equal(t, fruitChildren[0].ID, *grannySmith.ParentID)
+	assert.Len(t, fruit.ChildrenRecursive, 3)
+
+	nested := children(top[5])
+	assert.Len(t, nested, 1)
+	assert.Equal(t, TypeNumberedListItem, nested[0].Type)
+
+	// Image, code and quote
+	url, _ := top[7].Properties.GetString(PropertyKeyPublicURL)
+	alt, _ := top[7].Properties.GetString(PropertyKeyDescription)
+	assert.Equal(t, "https://example.com/cat.png", url)
+	assert.Equal(t, "A cat", alt)
+
+	language, _ := top[8].Properties.GetString(PropertyKeyLanguage)
+	assert.Equal(t, "go", language)
+	assert.Equal(t, "func main() {\n\n}", title(top[8]))
+
+	assert.Equal(t, "To be\nor not to be", title(top[9]))
+
+	// Every block is linked to the page and valid
+	for _, b := range result[1:] {
+		assert.Equal(t, page.ID, *b.RootParentID)
+		assert.Contains(t, byID[*b.ParentID].Content, b.ID)
+		assert.Empty(t, Validate(b), "block %s should be valid", b.Type)
+	}
+}
+
+func TestNewBlocksFromMarkdown_HeadingLevels(t *testing.T) {
+	result, err := NewBlocksFromMarkdown("###### Six\n\n####### Seven")
+	assert.NoError(t, err)
+	assert.Len(t, result, 3)
+
+	assert.Equal(t, TypeHeader6, result[1].Type)
+	assert.Equal(t, TypeParagraph, result[2].Type)
+	title, _ := result[2].Properties.GetString(PropertyKeyTitle)
+	assert.Equal(t, "####### Seven", title)
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("round trip with the markdown parser", func(t *testing.T) {
+		doc := `## Groceries
+
+Things to buy
+before the weekend
+
+- Fruit
+  - Apples
+    - [x] Granny Smith
+  - [ ] Pears
+- Bread
+
+  Whole grain only
+
+1. First
+2. Second
+   1. Nested
+   2. Nested again
+3. Third
+
+---
+
+![A cat](https://example.com/cat.png)
+
+` + "```go\nfunc main() {\n\n}\n```" + `
+
+> To be
+> or not to be
+
+https://example.com`
+
+		parsed, err := NewBlocksFromMarkdown(doc)
+		assert.NoError(t, err)
+
+		lookup := make(map[uuid.UUID]Block, len(parsed))
+		for _, b := range parsed {
+			lookup[b.ID] = b
+		}
+
+		rendered, err := RenderMarkdown(ctx, parsed[0], lookup)
+		assert.NoError(t, err)
+		assert.Equal(t, doc, rendered)
+	})
+
+	t.Run("page title round trip", func(t *testing.T) {
+		doc := "# Notes\n\n# Chapter\n\nbetween"
+
+		parsed, err := NewPageFromMarkdown(doc)
+		assert.NoError(t, err)
+		assert.Equal(t, "Notes", RenderPageProperties(parsed[0]))
+		assert.Equal(t, TypeHeader1, parsed[1].Type)
+
+		lookup := make(map[uuid.UUID]Block, len(parsed))
+		for _, b := range parsed {
+			lookup[b.ID] = b
+		}
+
+		rendered, err := RenderMarkdown(ctx, parsed[0], lookup)
+		assert.NoError(t, err)
+		assert.Equal(t, doc, rendered)
+
+		// The generic parser keeps the heading as a block
+		blocks, err := NewBlocksFromMarkdown(doc)
+		assert.NoError(t, err)
+		assert.Equal(t, "", RenderPageProperties(blocks[0]))
+		assert.Equal(t, TypeHeader1, blocks[1].Type)
+	})
+
+	t.Run("numbering restarts after an interruption", func(t *testing.T) {
+		item := func(title string) Block {
+			b := NewEmptyBlock()
+			b.Type = TypeNumberedListItem
+			b.Properties[PropertyKeyTitle] = []interface{}{title}
+			return b
+		}
+		one, two, three := item("one"), item("two"), item("three")
+		paragraph := NewEmptyBlock()
+		paragraph.Type = TypeParagraph
+		paragraph.Properties[PropertyKeyTitle] = []interface{}{"between"}
+
+		page := NewEmptyBlock()
+		page.Type = TypePage
+		page.Properties[PropertyKeyTitle] = []interface{}{"Notes"}
+		page.Content = []uuid.UUID{one.ID, two.ID, paragraph.ID, three.ID}
+
+		lookup := map[uuid.UUID]Block{one.ID: one, two.ID: two, three.ID: three, paragraph.ID: paragraph}
+
+		rendered, err := RenderMarkdown(ctx, page, lookup)
+		assert.NoError(t, err)
+		assert.Equal(t, "# Notes\n\n1. one\n2. two\n\nbetween\n\n1. three", rendered)
+	})
+
+	t.Run("cycles are rendered once", func(t *testing.T) {
+		parent := NewEmptyBlock()
+		parent.Type = TypeBulletListItem
+		parent.Properties[PropertyKeyTitle] = []interface{}{"parent"}
+		child := NewEmptyBlock()
+		child.Type = TypeBulletListItem
+		child.Properties[PropertyKeyTitle] = []interface{}{"child"}
+		parent.Content = []uuid.UUID{child.ID}
+		child.Content = []uuid.UUID{parent.ID}
+
+		lookup := map[uuid.UUID]Block{parent.ID: parent, child.ID: child}
+
+		rendered, err := RenderMarkdown(ctx, parent, lookup)
+		assert.NoError(t, err)
+		assert.Equal(t, "- parent\n  - child", rendered)
+	})
+}
@@ -0,0 +1,185 @@
+package blocks
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+var rawMessageType = reflect.TypeOf(json.RawMessage{})
+
+// mergePatchField applies a JSON merge patch to a single struct field and reports whether the field changed.
+// The field is only modified if the whole patch can be applied.
+func mergePatchField(field reflect.Value, patch json.RawMessage) (bool, error) {
+	if properties, ok := field.Addr().Interface().(*Properties); ok {
+		return properties.mergePatch(patch)
+	}
+
+	var newValue reflect.Value
+	switch {
+	case isJSONNull(patch):
+		newValue = reflect.New(field.Type()).Elem()
+		if field.Kind() == reflect.Map {
+			newValue = reflect.MakeMap(field.Type())
+		}
+	case isJSONObject(patch) && isMergeableKind(field.Type()):
+		merged, err := mergePatchJSON(field.Interface(), patch)
+		if err != nil {
+			return false, err
+		}
+		newValue = reflect.New(field.Type())
+		if err := json.Unmarshal(merged, newValue.Interface()); err != nil {
+			return false, err
+		}
+		newValue = newValue.Elem()
+	default:
+		newValue = reflect.New(field.Type())
+		if err := json.Unmarshal(patch, newValue.Interface()); err != nil {
+			return false, err
+		}
+		newValue = newValue.Elem()
+	}
+
+	if equalFieldValues(field, newValue) {
+		return false, nil
+	}
+	field.Set(newValue)
+	return true, nil
+}
+
+// mergePatch merges the properties key by key: null removes a key and any other value replaces it
+func (p *Properties) mergePatch(patch json.RawMessage) (bool, error) {
+	if isJSONNull(patch) {
+		if len(*p) == 0 {
+			return false, nil
+		}
+		*p = Properties{}
+		return true, nil
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &members); err != nil {
+		return false, err
+	}
+
+	// Decode every member first so a single invalid value leaves the properties untouched
+	decoded := make(map[string][]interface{}, len(members))
+	for key, raw := range members {
+		if isJSONNull(raw) {
+			continue
+		}
+		values, err := decodePropertyValues(key, raw)
+		if err != nil {
+			return false, err
+		}
+		decoded[key] = values
+	}
+
+	if *p == nil {
+		*p = Properties{}
+	}
+
+	keys := make([]string, 0, len(members))
+	for key := range members {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	changed := false
+	for _, key := range keys {
+		values, set := decoded[key]
+		current, exists := (*p)[key]
+		switch {
+		case !set && exists:
+			delete(*p, key)
+			changed = true
+		case set && (!exists || !reflect.DeepEqual(current, values)):
+			(*p)[key] = values
+			changed = true
+		}
+	}
+	return changed, nil
+}
+
+// mergePatchJSON applies the patch to the JSON representation of the value, as defined by RFC 7396
+func mergePatchJSON(value interface{}, patch json.RawMessage) ([]byte, error) {
+	current, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var target, patchValue interface{}
+	if err := json.Unmarshal(current, &target); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patch, &patchValue); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(applyMergePatch(target, patchValue))
+}
+
+// applyMergePatch implements the MergePatch function of RFC 7396 on decoded JSON values
+func applyMergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{})
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = applyMergePatch(targetObject[key], value)
+	}
+	return targetObject
+}
+
+// isMergeableKind reports whether values of the type are JSON objects that a patch object is merged into
+func isMergeableKind(t reflect.Type) bool {
+	if t == rawMessageType {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Map:
+		return true
+	case reflect.Struct:
+		// Structs with their own encoding, like time.Time, are not objects
+		_, custom := reflect.New(t).Interface().(json.Marshaler)
+		return !custom
+	}
+	return false
+}
+
+// equalFieldValues compares the current and the new field value.
+// Raw JSON is compared by its decoded value and nil maps are equal to empty maps.
+func equalFieldValues(field, newValue reflect.Value) bool {
+	if field.Type() == rawMessageType {
+		var current, updated interface{}
+		currentErr := json.Unmarshal(field.Bytes(), &current)
+		updatedErr := json.Unmarshal(newValue.Bytes(), &updated)
+		if currentErr == nil && updatedErr == nil {
+			return reflect.DeepEqual(current, updated)
+		}
+	}
+	if field.Kind() == reflect.Map && field.Len() == 0 && newValue.Len() == 0 {
+		return true
+	}
+	return reflect.DeepEqual(field.Interface(), newValue.Interface())
+}
+
+func isJSONNull(raw json.RawMessage) bool {
+	return bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+}
+
+func isJSONObject(raw json.RawMessage) bool {
+	trimmed := bytes.TrimSpace(raw)
+	return len(trimmed) > 0 && trimmed[0] == '{'
+}
@@ -28,4 +28,33 @@ type Move struct {
 	Reasoning         string          `json:"reasoning"`
 	ReasoningKeywords []string        `json:"reasoning_keywords"`
 	SpaceKeywords     []string        `json:"space_keywords"`
+
+	// FromSpaceID and ToSpaceID are the spaces of the block before and after the move.
+	// They are nil for moves recorded before the spaces were tracked.
+	FromSpaceID *uuid.UUID `json:"from_space_id,omitempty"`
+	ToSpaceID   *uuid.UUID `json:"to_space_id,omitempty"`
+	// FromIndex is the position of the block in the Content of its previous parent, nil if it was a root
+	FromIndex *int `json:"from_index,omitempty"`
+}
+
+// FromSpace returns the space the block was in before the move, if known
+func (m Move) FromSpace() (uuid.UUID, bool) {
+	if m.FromSpaceID != nil {
+		return *m.FromSpaceID, true
+	}
+	if m.FromType == DestinationTypeSpace {
+		return m.FromID, true
+	}
+	return uuid.Nil, false
+}
+
+// ToSpace returns the space the block was moved to, if known
+func (m Move) ToSpace() (uuid.UUID, bool) {
+	if m.ToSpaceID != nil {
+		return *m.ToSpaceID, true
+	}
+	if m.ToType == DestinationTypeSpace {
+		return m.ToID, true
+	}
+	return uuid.Nil, false
 }
@@ -0,0 +1,262 @@
+package blocks
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MoveOptions describes why and where a subtree is moved
+type MoveOptions struct {
+	Reason            MoveReason
+	Accuracy          float64
+	Reasoning         string
+	ReasoningKeywords []string
+	SpaceKeywords     []string
+
+	// AfterID places the block after this sibling in the new parent's Content; nil appends it
+	AfterID *uuid.UUID
+	// Timestamp of the move; zero means now
+	Timestamp time.Time
+	// HistoryLimit is the number of moves kept in the history of the moved block.
+	// Zero uses DefaultMovesHistoryLimit and a negative limit keeps the whole history.
+	HistoryLimit int
+}
+
+// MoveSubtree moves a block and all its descendants to another block, note or space.
+//
+// For DestinationTypeBlock and DestinationTypeNote the block becomes a child of toID, which must be in the tree
+// and must not be part of the moved subtree; a note is a root block. For DestinationTypeSpace the block becomes
+// a root block of the space toID.
+//
+// ParentID, the Content of the old and new parents, ChildrenRecursive of both ancestor chains, RootParentID of
+// every moved block and SpaceID/PreviousSpaceID are updated, and a Move is recorded on the moved block.
+// The tree is updated and the changed blocks are returned in tree order for persistence.
+func MoveSubtree(tree *BlockTree, blockID uuid.UUID, toType DestinationType, toID uuid.UUID, options MoveOptions) ([]Block, error) {
+	b, exists := tree.Get(blockID)
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrBlockNotFound, blockID)
+	}
+
+	subtree := append([]uuid.UUID{blockID}, tree.descendantIDs(blockID)...)
+
+	var target Block
+	switch toType {
+	case DestinationTypeSpace:
+	case DestinationTypeBlock, DestinationTypeNote:
+		target, exists = tree.Get(toID)
+		if !exists {
+			return nil, fmt.Errorf("%w: %s", ErrBlockNotFound, toID)
+		}
+		if containsID(subtree, toID) {
+			return nil, fmt.Errorf("%w: cannot move block %s into its own subtree", ErrInvalidMove, blockID)
+		}
+		if toType == DestinationTypeNote && target.ParentID != nil {
+			return nil, fmt.Errorf("%w: destination %s is not a note", ErrInvalidMove, toID)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown destination type %d", ErrInvalidMove, toType)
+	}
+
+	fromType, fromID := moveOrigin(tree, b)
+
+	now := options.Timestamp
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	changed := make(map[uuid.UUID]*Block)
+	edit := func(id uuid.UUID) *Block {
+		if cb, ok := changed[id]; ok {
+			return cb
+		}
+		cb, _ := tree.Get(id)
+		// Copy the slices so that the blocks held by the caller are not modified
+		cb.Content = append([]uuid.UUID{}, cb.Content...)
+		cb.ChildrenRecursive = append([]uuid.UUID{}, cb.ChildrenRecursive...)
+		cb.MovesHistory = append([]Move{}, cb.MovesHistory...)
+		cb.UpdatedAt = now
+		changed[id] = &cb
+		return &cb
+	}
+
+	// Detach from the old parent and its ancestors
+	var fromIndex *int
+	if parent, ok := tree.Parent(blockID); ok {
+		for i, childID := range parent.Content {
+			if childID == blockID {
+				index := i
+				fromIndex = &index
+				break
+			}
+		}
+		_ = edit(parent.ID).RemoveChild(blockID)
+		for _, ancestorID := range append([]uuid.UUID{parent.ID}, tree.ancestorIDs(parent.ID)...) {
+			ancestor := edit(ancestorID)
+			ancestor.ChildrenRecursive = removeIDs(ancestor.ChildrenRecursive, subtree)
+		}
+	}
+
+	// Attach to the new parent and its ancestors
+	var parentID, rootID *uuid.UUID
+	spaceID := toID
+	if toType != DestinationTypeSpace {
+		newParent := edit(toID)
+		if options.AfterID != nil {
+			if err := newParent.InsertChild(blockID, *options.AfterID); err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrInvalidMove, err)
+			}
+		} else {
+			newParent.AppendChild(blockID)
+		}
+		for _, ancestorID := range append([]uuid.UUID{toID}, tree.ancestorIDs(toID)...) {
+			ancestor := edit(ancestorID)
+			for _, id := range subtree {
+				if !containsID(ancestor.ChildrenRecursive, id) {
+					ancestor.ChildrenRecursive = append(ancestor.ChildrenRecursive, id)
+				}
+			}
+		}
+
+		parentID = &target.ID
+		rootID = &target.ID
+		if target.RootParentID != nil {
+			rootID = copyIDPointer(target.RootParentID)
+		}
+		spaceID = target.SpaceID
+	}
+
+	moved := edit(blockID)
+	moved.ParentID = parentID
+	moved.RootParentID = rootID
+
+	descendantRootID := rootID
+	if descendantRootID == nil {
+		descendantRootID = &moved.ID
+	}
+	for _, id := range subtree {
+		cb := edit(id)
+		if id != blockID {
+			cb.RootParentID = copyIDPointer(descendantRootID)
+		}
+		if cb.SpaceID != spaceID {
+			cb.PreviousSpaceID = cb.SpaceID
+			cb.SpaceID = spaceID
+		}
+	}
+
+	historyLimit := options.HistoryLimit
+	if historyLimit == 0 {
+		historyLimit = DefaultMovesHistoryLimit
+	}
+	moved.AddMoveWithLimit(Move{
+		FromType:          fromType,
+		FromID:            fromID,
+		ToType:            toType,
+		ToID:              toID,
+		Timestamp:         now,
+		Reason:            options.Reason,
+		Accuracy:          options.Accuracy,
+		Reasoning:         options.Reasoning,
+		ReasoningKeywords: options.ReasoningKeywords,
+		SpaceKeywords:     options.SpaceKeywords,
+		FromSpaceID:       copyIDPointer(&b.SpaceID),
+		ToSpaceID:         copyIDPointer(&spaceID),
+		FromIndex:         fromIndex,
+	}, historyLimit)
+
+	result := make([]Block, 0, len(changed))
+	for _, id := range tree.order {
+		if cb, ok := changed[id]; ok {
+			result = append(result, *cb)
+		}
+	}
+	tree.putAll(result)
+
+	return result, nil
+}
+
+// UndoLastMove moves the block back to where its last recorded move came from and removes that move from its history.
+//
+// The subtree is moved with MoveSubtree, so the tree stays consistent, but no new move is recorded. The block
+// returns to its former position among its siblings if the move recorded it. The blocks get their SpaceID from
+// before the move again and their PreviousSpaceID is set to the space the block left with the latest remaining
+// move that changed its space, or uuid.Nil if the history has none.
+// The tree is updated and the changed blocks are returned in tree order for persistence.
+func UndoLastMove(tree *BlockTree, blockID uuid.UUID, timestamp time.Time) ([]Block, error) {
+	b, exists := tree.Get(blockID)
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrBlockNotFound, blockID)
+	}
+	if len(b.MovesHistory) == 0 {
+		return nil, fmt.Errorf("%w: block %s has no move to undo", ErrInvalidMove, blockID)
+	}
+
+	history := append([]Move{}, b.MovesHistory[:len(b.MovesHistory)-1]...)
+	last := b.MovesHistory[len(b.MovesHistory)-1]
+
+	changed, err := MoveSubtree(tree, blockID, last.FromType, last.FromID, MoveOptions{Timestamp: timestamp, HistoryLimit: -1})
+	if err != nil {
+		return nil, fmt.Errorf("failed to undo move of block %s: %w", blockID, err)
+	}
+
+	var previousSpaceID uuid.UUID
+	for i := len(history) - 1; i >= 0; i-- {
+		from, fromKnown := history[i].FromSpace()
+		to, toKnown := history[i].ToSpace()
+		if fromKnown && toKnown && from != to {
+			previousSpaceID = from
+			break
+		}
+	}
+
+	moved, _ := tree.Get(blockID)
+	for i := range changed {
+		cb := &changed[i]
+		if cb.ID == blockID {
+			cb.MovesHistory = history
+		}
+		if cb.SpaceID != b.SpaceID && cb.PreviousSpaceID == b.SpaceID {
+			cb.PreviousSpaceID = previousSpaceID
+		}
+		if last.FromIndex != nil && moved.ParentID != nil && cb.ID == *moved.ParentID {
+			cb.Content = insertIDAt(removeIDs(cb.Content, []uuid.UUID{blockID}), blockID, *last.FromIndex)
+		}
+	}
+	tree.putAll(changed)
+
+	return changed, nil
+}
+
+// insertIDAt inserts the ID at the index, or appends it if the index is out of range
+func insertIDAt(ids []uuid.UUID, id uuid.UUID, index int) []uuid.UUID {
+	if index < 0 || index >= len(ids) {
+		return append(ids, id)
+	}
+	inserted := append([]uuid.UUID{}, ids[:index]...)
+	inserted = append(inserted, id)
+	return append(inserted, ids[index:]...)
+}
+
+// moveOrigin returns where the block currently lives, as recorded in a Move
+func moveOrigin(tree *BlockTree, b Block) (DestinationType, uuid.UUID) {
+	if b.ParentID == nil {
+		return DestinationTypeSpace, b.SpaceID
+	}
+	if parent, ok := tree.Get(*b.ParentID); ok && parent.ParentID == nil {
+		return DestinationTypeNote, parent.ID
+	}
+	return DestinationTypeBlock, *b.ParentID
+}
+
+// removeIDs returns the IDs that are not in the removed list
+func removeIDs(ids []uuid.UUID, removed []uuid.UUID) []uuid.UUID {
+	kept := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !containsID(removed, id) {
+			kept = append(kept, id)
+		}
+	}
+	return kept
+}
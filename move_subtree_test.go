@@ -0,0 +1,196 @@
+package blocks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoveSubtree(t *testing.T) {
+	newTree := func(t *testing.T) (*BlockTree, Block, Block, Block, Block, Block) {
+		page, a, a1, a2, b := newTestTreeBlocks()
+		tree, err := NewBlockTree([]Block{page, a, a1, a2, b})
+		assert.NoError(t, err)
+		return tree, page, a, a1, a2, b
+	}
+
+	assertConsistent := func(t *testing.T, tree *BlockTree) {
+		report, err := CheckIntegrity(tree.Blocks())
+		assert.NoError(t, err)
+		assert.False(t, report.HasIssues(), "%v", report.Issues)
+	}
+
+	t.Run("move to another block", func(t *testing.T) {
+		tree, page, a, a1, a2, b := newTree(t)
+
+		changed, err := MoveSubtree(tree, a1.ID, DestinationTypeBlock, b.ID, MoveOptions{Reason: MoveReasonRouter, Accuracy: 0.8})
+		assert.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{page.ID, a.ID, a1.ID, b.ID}, blockIDs(changed))
+		assertConsistent(t, tree)
+
+		assert.Equal(t, []uuid.UUID{a2.ID}, blockIDs(tree.Children(a.ID)))
+		assert.Equal(t, []uuid.UUID{a1.ID}, blockIDs(tree.Children(b.ID)))
+
+		moved, _ := tree.Get(a1.ID)
+		assert.Equal(t, b.ID, *moved.ParentID)
+		assert.Len(t, moved.MovesHistory, 1)
+		space, index := uuid.Nil, 0
+		assert.Equal(t, Move{
+			FromType:    DestinationTypeBlock,
+			FromID:      a.ID,
+			ToType:      DestinationTypeBlock,
+			ToID:        b.ID,
+			Timestamp:   moved.MovesHistory[0].Timestamp,
+			Reason:      MoveReasonRouter,
+			Accuracy:    0.8,
+			FromSpaceID: &space,
+			ToSpaceID:   &space,
+			FromIndex:   &index,
+		}, moved.MovesHistory[0])
+
+		// The blocks held by the caller are untouched
+		assert.Equal(t, []uuid.UUID{a1.ID, a2.ID}, a.Content)
+	})
+
+	t.Run("move to a space", func(t *testing.T) {
+		tree, page, a, a1, a2, b := newTree(t)
+		space := uuid.New()
+
+		_, err := MoveSubtree(tree, a.ID, DestinationTypeSpace, space, MoveOptions{})
+		assert.NoError(t, err)
+		assertConsistent(t, tree)
+
+		assert.ElementsMatch(t, []uuid.UUID{page.ID, a.ID}, blockIDs(tree.Roots()))
+		updatedPage, _ := tree.Get(page.ID)
+		assert.Equal(t, []uuid.UUID{b.ID}, updatedPage.ChildrenRecursive)
+
+		for _, id := range []uuid.UUID{a.ID, a1.ID, a2.ID} {
+			moved, _ := tree.Get(id)
+			assert.Equal(t, space, moved.SpaceID)
+			assert.Equal(t, uuid.Nil, moved.PreviousSpaceID)
+		}
+		child, _ := tree.Get(a1.ID)
+		assert.Equal(t, a.ID, *child.RootParentID)
+
+		// Move it back into the page as a note, before b
+		_, err = MoveSubtree(tree, a.ID, DestinationTypeNote, page.ID, MoveOptions{AfterID: &b.ID})
+		assert.NoError(t, err)
+		assertConsistent(t, tree)
+		assert.Equal(t, []uuid.UUID{b.ID, a.ID}, blockIDs(tree.Children(page.ID)))
+
+		moved, _ := tree.Get(a.ID)
+		assert.Equal(t, space, moved.PreviousSpaceID)
+		assert.Len(t, moved.MovesHistory, 2)
+		assert.Equal(t, DestinationTypeSpace, moved.MovesHistory[1].FromType)
+		assert.Equal(t, space, moved.MovesHistory[1].FromID)
+	})
+
+	t.Run("undo last move", func(t *testing.T) {
+		tree, page, a, a1, _, b := newTree(t)
+		space, otherSpace := uuid.New(), uuid.New()
+
+		_, err := MoveSubtree(tree, a.ID, DestinationTypeSpace, space, MoveOptions{})
+		assert.NoError(t, err)
+		_, err = MoveSubtree(tree, a.ID, DestinationTypeSpace, otherSpace, MoveOptions{})
+		assert.NoError(t, err)
+
+		_, err = UndoLastMove(tree, a.ID, time.Now())
+		assert.NoError(t, err)
+		assertConsistent(t, tree)
+
+		moved, _ := tree.Get(a.ID)
+		assert.Equal(t, space, moved.SpaceID)
+		assert.Equal(t, uuid.Nil, moved.PreviousSpaceID)
+		assert.Len(t, moved.MovesHistory, 1)
+		child, _ := tree.Get(a1.ID)
+		assert.Equal(t, space, child.SpaceID)
+
+		changed, err := UndoLastMove(tree, a.ID, time.Now())
+		assert.NoError(t, err)
+		assert.Contains(t, blockIDs(changed), page.ID)
+		assertConsistent(t, tree)
+		// a returns to its former position before b
+		assert.Equal(t, []uuid.UUID{a.ID, b.ID}, blockIDs(tree.Children(page.ID)))
+
+		moved, _ = tree.Get(a.ID)
+		assert.Equal(t, page.ID, *moved.ParentID)
+		assert.Equal(t, uuid.Nil, moved.SpaceID)
+		assert.Equal(t, uuid.Nil, moved.PreviousSpaceID)
+		assert.Empty(t, moved.MovesHistory)
+
+		_, err = UndoLastMove(tree, a.ID, time.Now())
+		assert.ErrorIs(t, err, ErrInvalidMove)
+	})
+
+	t.Run("undo restores the space left from a note", func(t *testing.T) {
+		page, a, a1, a2, b := newTestTreeBlocks()
+		noteSpace := uuid.New()
+		for _, block := range []*Block{&page, &a, &a1, &a2, &b} {
+			block.SpaceID = noteSpace
+		}
+		tree, err := NewBlockTree([]Block{page, a, a1, a2, b})
+		assert.NoError(t, err)
+
+		space, otherSpace := uuid.New(), uuid.New()
+		_, err = MoveSubtree(tree, a.ID, DestinationTypeSpace, space, MoveOptions{})
+		assert.NoError(t, err)
+		_, err = MoveSubtree(tree, a.ID, DestinationTypeSpace, otherSpace, MoveOptions{})
+		assert.NoError(t, err)
+
+		_, err = UndoLastMove(tree, a.ID, time.Now())
+		assert.NoError(t, err)
+
+		moved, _ := tree.Get(a.ID)
+		assert.Equal(t, space, moved.SpaceID)
+		assert.Equal(t, noteSpace, moved.PreviousSpaceID)
+		child, _ := tree.Get(a2.ID)
+		assert.Equal(t, noteSpace, child.PreviousSpaceID)
+	})
+
+	t.Run("history limit", func(t *testing.T) {
+		tree, _, a, _, _, _ := newTree(t)
+
+		for i := 0; i < DefaultMovesHistoryLimit+5; i++ {
+			_, err := MoveSubtree(tree, a.ID, DestinationTypeSpace, uuid.New(), MoveOptions{})
+			assert.NoError(t, err)
+		}
+		moved, _ := tree.Get(a.ID)
+		assert.Len(t, moved.MovesHistory, DefaultMovesHistoryLimit)
+
+		_, err := MoveSubtree(tree, a.ID, DestinationTypeSpace, uuid.New(), MoveOptions{HistoryLimit: 3})
+		assert.NoError(t, err)
+		moved, _ = tree.Get(a.ID)
+		assert.Len(t, moved.MovesHistory, 3)
+
+		for i := 0; i < DefaultMovesHistoryLimit; i++ {
+			_, err := MoveSubtree(tree, a.ID, DestinationTypeSpace, uuid.New(), MoveOptions{HistoryLimit: -1})
+			assert.NoError(t, err)
+		}
+		moved, _ = tree.Get(a.ID)
+		assert.Len(t, moved.MovesHistory, DefaultMovesHistoryLimit+3)
+	})
+
+	t.Run("invalid moves", func(t *testing.T) {
+		tree, page, a, a1, _, b := newTree(t)
+
+		_, err := MoveSubtree(tree, a.ID, DestinationTypeBlock, a1.ID, MoveOptions{})
+		assert.ErrorIs(t, err, ErrInvalidMove)
+
+		_, err = MoveSubtree(tree, a1.ID, DestinationTypeNote, b.ID, MoveOptions{})
+		assert.ErrorIs(t, err, ErrInvalidMove)
+
+		_, err = MoveSubtree(tree, page.ID, DestinationType(42), b.ID, MoveOptions{})
+		assert.ErrorIs(t, err, ErrInvalidMove)
+
+		_, err = MoveSubtree(tree, a1.ID, DestinationTypeBlock, uuid.New(), MoveOptions{})
+		assert.ErrorIs(t, err, ErrBlockNotFound)
+
+		missing := uuid.New()
+		_, err = MoveSubtree(tree, a1.ID, DestinationTypeBlock, b.ID, MoveOptions{AfterID: &missing})
+		assert.ErrorIs(t, err, ErrInvalidMove)
+
+		assertConsistent(t, tree)
+	})
+}
@@ -381,3 +381,14 @@ func GetMovieProperties() []string {
 		PropertyKeyChecked,
 	}
 }
+
+func init() {
+	mustRegisterType(TypeDefinition{
+		DataType:     TypeMovie,
+		RenderFunc:   renderWith(RenderMovieProperties),
+		PropertyKeys: GetMovieProperties(),
+		Content:      BlockContentTypeStructural,
+		Final:        true,
+		Schema:       NewPropertySchema(GetMovieProperties()...).Require(PropertyKeyTitle),
+	})
+}
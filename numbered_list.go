@@ -37,3 +37,12 @@ func GetNumberedListItemProperties() []string {
 		PropertyKeyTitle,
 	}
 }
+
+func init() {
+	mustRegisterType(TypeDefinition{
+		DataType:     TypeNumberedListItem,
+		RenderFunc:   renderWith(RenderNumberedListItemProperties),
+		PropertyKeys: GetNumberedListItemProperties(),
+		Content:      BlockContentTypeTextual,
+	})
+}
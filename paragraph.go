@@ -35,3 +35,12 @@ func GetParagraphProperties() []string {
 		PropertyKeyTitle,
 	}
 }
+
+func init() {
+	mustRegisterType(TypeDefinition{
+		DataType:     TypeParagraph,
+		RenderFunc:   renderWith(RenderParagraphProperties),
+		PropertyKeys: GetParagraphProperties(),
+		Content:      BlockContentTypeTextual,
+	})
+}
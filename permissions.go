@@ -0,0 +1,256 @@
+package blocks
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Role is a set of actions granted on a block or space
+type Role string
+
+const (
+	RoleOwner     Role = "owner"
+	RoleEditor    Role = "editor"
+	RoleCommenter Role = "commenter"
+	RoleViewer    Role = "viewer"
+)
+
+// Action is something a principal does with a block
+type Action string
+
+const (
+	ActionView    Action = "view"
+	ActionComment Action = "comment"
+	ActionEdit    Action = "edit"
+	ActionShare   Action = "share"
+	ActionDelete  Action = "delete"
+)
+
+var roleActions = map[Role][]Action{
+	RoleOwner:     {ActionView, ActionComment, ActionEdit, ActionShare, ActionDelete},
+	RoleEditor:    {ActionView, ActionComment, ActionEdit},
+	RoleCommenter: {ActionView, ActionComment},
+	RoleViewer:    {ActionView},
+}
+
+// Allows reports whether the role includes the action
+func (r Role) Allows(action Action) bool {
+	for _, allowed := range roleActions[r] {
+		if allowed == action {
+			return true
+		}
+	}
+	return false
+}
+
+// GranteeKind tells whether a grant is given to a user or a group
+type GranteeKind string
+
+const (
+	GranteeUser  GranteeKind = "user"
+	GranteeGroup GranteeKind = "group"
+)
+
+// ResourceKind tells whether a grant is given on a block or on a space
+type ResourceKind string
+
+const (
+	ResourceBlock ResourceKind = "block"
+	ResourceSpace ResourceKind = "space"
+)
+
+// Grant gives a role to a user or group on a block, and its descendants, or on a whole space
+type Grant struct {
+	GranteeKind  GranteeKind  `json:"grantee_kind"`
+	GranteeID    uuid.UUID    `json:"grantee_id"`
+	Role         Role         `json:"role"`
+	ResourceKind ResourceKind `json:"resource_kind"`
+	ResourceID   uuid.UUID    `json:"resource_id"`
+}
+
+func (g Grant) String() string {
+	return fmt.Sprintf("%s granted to %s %s on %s %s", g.Role, g.GranteeKind, g.GranteeID, g.ResourceKind, g.ResourceID)
+}
+
+// Principal is the user performing an action, with the account it belongs to and its group memberships
+type Principal struct {
+	UserID    uuid.UUID   `json:"user_id"`
+	AccountID uuid.UUID   `json:"account_id"`
+	GroupIDs  []uuid.UUID `json:"group_ids"`
+}
+
+// matches reports whether the grant is given to the principal
+func (p Principal) matches(g Grant) bool {
+	switch g.GranteeKind {
+	case GranteeUser:
+		return g.GranteeID == p.UserID
+	case GranteeGroup:
+		return containsID(p.GroupIDs, g.GranteeID)
+	}
+	return false
+}
+
+// AccessDecision is the result of a permission check, with the grant that decided it and a readable reason
+type AccessDecision struct {
+	Allowed bool   `json:"allowed"`
+	Action  Action `json:"action"`
+	Role    Role   `json:"role,omitempty"`
+	Grant   *Grant `json:"grant,omitempty"`
+	Reason  string `json:"reason"`
+}
+
+// Err returns nil if access was granted, and ErrUnauthorizedBlockAccess with the reason otherwise
+func (d AccessDecision) Err() error {
+	if d.Allowed {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrUnauthorizedBlockAccess, d.Reason)
+}
+
+// AccessControl holds the grants on blocks and spaces and evaluates permissions.
+// Grants on a block apply to all its descendants, found through ParentID using the lookup map
+// and through RootParentID, and grants on a space apply to all blocks in the space.
+// The account owning a block is always its owner. It is safe for concurrent use.
+type AccessControl struct {
+	mu           sync.RWMutex
+	grants       map[ResourceKind]map[uuid.UUID][]Grant
+	lookupBlocks map[uuid.UUID]Block
+}
+
+// NewAccessControl creates an access control without grants; the lookup map is used to find ancestors
+func NewAccessControl(lookupBlocks map[uuid.UUID]Block) *AccessControl {
+	return &AccessControl{
+		grants: map[ResourceKind]map[uuid.UUID][]Grant{
+			ResourceBlock: {},
+			ResourceSpace: {},
+		},
+		lookupBlocks: lookupBlocks,
+	}
+}
+
+// Grant adds a grant, replacing the role of an existing grant to the same grantee on the same resource
+func (ac *AccessControl) Grant(g Grant) error {
+	if _, valid := roleActions[g.Role]; !valid {
+		return fmt.Errorf("unknown role %q", g.Role)
+	}
+	if g.GranteeKind != GranteeUser && g.GranteeKind != GranteeGroup {
+		return fmt.Errorf("unknown grantee kind %q", g.GranteeKind)
+	}
+
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	resources, valid := ac.grants[g.ResourceKind]
+	if !valid {
+		return fmt.Errorf("unknown resource kind %q", g.ResourceKind)
+	}
+	for i, existing := range resources[g.ResourceID] {
+		if existing.GranteeKind == g.GranteeKind && existing.GranteeID == g.GranteeID {
+			resources[g.ResourceID][i] = g
+			return nil
+		}
+	}
+	resources[g.ResourceID] = append(resources[g.ResourceID], g)
+	return nil
+}
+
+// Revoke removes the grant of the grantee on the resource and reports whether it existed
+func (ac *AccessControl) Revoke(resourceKind ResourceKind, resourceID uuid.UUID, granteeKind GranteeKind, granteeID uuid.UUID) bool {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	grants := ac.grants[resourceKind][resourceID]
+	for i, existing := range grants {
+		if existing.GranteeKind == granteeKind && existing.GranteeID == granteeID {
+			ac.grants[resourceKind][resourceID] = append(grants[:i], grants[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Grants returns the grants given directly on the resource
+func (ac *AccessControl) Grants(resourceKind ResourceKind, resourceID uuid.UUID) []Grant {
+	ac.mu.RLock()
+	defer ac.mu.RUnlock()
+	return append([]Grant{}, ac.grants[resourceKind][resourceID]...)
+}
+
+// Can decides whether the principal may perform the action on the block.
+// The block itself is checked first, then its ancestors from the nearest, its root and finally its space;
+// the first grant allowing the action decides. If no grant allows it, the reason lists the grants found.
+func (ac *AccessControl) Can(principal Principal, action Action, b Block) AccessDecision {
+	decision := AccessDecision{Action: action}
+
+	if principal.AccountID != uuid.Nil && b.AccountID == principal.AccountID {
+		decision.Allowed = RoleOwner.Allows(action)
+		decision.Role = RoleOwner
+		decision.Reason = fmt.Sprintf("account %s owns block %s", b.AccountID, b.ID)
+		return decision
+	}
+
+	ac.mu.RLock()
+	defer ac.mu.RUnlock()
+
+	var insufficient []string
+	for _, resource := range ac.resourceChain(b) {
+		for _, g := range ac.grants[resource.kind][resource.id] {
+			if !principal.matches(g) {
+				continue
+			}
+			if !g.Role.Allows(action) {
+				insufficient = append(insufficient, g.String())
+				continue
+			}
+
+			grant := g
+			decision.Allowed = true
+			decision.Role = g.Role
+			decision.Grant = &grant
+			decision.Reason = g.String()
+			if resource.kind != ResourceBlock || resource.id != b.ID {
+				decision.Reason += fmt.Sprintf(", inherited by block %s", b.ID)
+			}
+			return decision
+		}
+	}
+
+	if len(insufficient) == 0 {
+		decision.Reason = fmt.Sprintf("no grant for user %s on block %s, its ancestors or space %s", principal.UserID, b.ID, b.SpaceID)
+	} else {
+		decision.Reason = fmt.Sprintf("%s not allowed by %s", action, strings.Join(insufficient, "; "))
+	}
+	return decision
+}
+
+type resourceRef struct {
+	kind ResourceKind
+	id   uuid.UUID
+}
+
+// resourceChain returns the block, its ancestors from the nearest, its root and its space
+func (ac *AccessControl) resourceChain(b Block) []resourceRef {
+	chain := []resourceRef{{ResourceBlock, b.ID}}
+	visited := map[uuid.UUID]bool{b.ID: true}
+
+	parentID := b.ParentID
+	for parentID != nil && !visited[*parentID] {
+		visited[*parentID] = true
+		chain = append(chain, resourceRef{ResourceBlock, *parentID})
+
+		parent, exists := ac.lookupBlocks[*parentID]
+		if !exists {
+			break
+		}
+		parentID = parent.ParentID
+	}
+
+	if b.RootParentID != nil && !visited[*b.RootParentID] {
+		chain = append(chain, resourceRef{ResourceBlock, *b.RootParentID})
+	}
+
+	return append(chain, resourceRef{ResourceSpace, b.SpaceID})
+}
@@ -0,0 +1,90 @@
+package blocks
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessControl(t *testing.T) {
+	page, a, a1, _, _ := newTestTreeBlocks()
+	space := uuid.New()
+	for _, b := range []*Block{&page, &a, &a1} {
+		b.SpaceID = space
+		b.AccountID = uuid.New()
+	}
+	// a1 only knows its root, a is known through the lookup map
+	lookup := map[uuid.UUID]Block{page.ID: page, a.ID: a, a1.ID: a1}
+
+	alice := Principal{UserID: uuid.New(), AccountID: uuid.New()}
+	team := uuid.New()
+	bob := Principal{UserID: uuid.New(), AccountID: uuid.New(), GroupIDs: []uuid.UUID{team}}
+
+	ac := NewAccessControl(lookup)
+	assert.NoError(t, ac.Grant(Grant{GranteeKind: GranteeUser, GranteeID: alice.UserID, Role: RoleViewer, ResourceKind: ResourceSpace, ResourceID: space}))
+	assert.NoError(t, ac.Grant(Grant{GranteeKind: GranteeUser, GranteeID: alice.UserID, Role: RoleEditor, ResourceKind: ResourceBlock, ResourceID: a.ID}))
+	assert.NoError(t, ac.Grant(Grant{GranteeKind: GranteeGroup, GranteeID: team, Role: RoleCommenter, ResourceKind: ResourceBlock, ResourceID: page.ID}))
+
+	t.Run("inherited from the nearest ancestor", func(t *testing.T) {
+		decision := ac.Can(alice, ActionEdit, a1)
+		assert.True(t, decision.Allowed)
+		assert.NoError(t, decision.Err())
+		assert.Equal(t, RoleEditor, decision.Role)
+		assert.Equal(t, a.ID, decision.Grant.ResourceID)
+		assert.Contains(t, decision.Reason, "inherited by block "+a1.ID.String())
+	})
+
+	t.Run("space grant", func(t *testing.T) {
+		decision := ac.Can(alice, ActionView, page)
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, ResourceSpace, decision.Grant.ResourceKind)
+
+		decision = ac.Can(alice, ActionEdit, page)
+		assert.False(t, decision.Allowed)
+		assert.ErrorIs(t, decision.Err(), ErrUnauthorizedBlockAccess)
+		assert.Equal(t, "edit not allowed by viewer granted to user "+alice.UserID.String()+" on space "+space.String(), decision.Reason)
+	})
+
+	t.Run("group grant through the root", func(t *testing.T) {
+		decision := ac.Can(bob, ActionComment, a1)
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, RoleCommenter, decision.Role)
+		assert.Equal(t, GranteeGroup, decision.Grant.GranteeKind)
+
+		assert.False(t, ac.Can(bob, ActionDelete, a1).Allowed)
+	})
+
+	t.Run("account owner", func(t *testing.T) {
+		owner := Principal{UserID: uuid.New(), AccountID: a1.AccountID}
+		decision := ac.Can(owner, ActionDelete, a1)
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, RoleOwner, decision.Role)
+		assert.Nil(t, decision.Grant)
+	})
+
+	t.Run("no grant", func(t *testing.T) {
+		decision := ac.Can(Principal{UserID: uuid.New()}, ActionView, a1)
+		assert.False(t, decision.Allowed)
+		assert.Contains(t, decision.Reason, "no grant")
+	})
+
+	t.Run("grant management", func(t *testing.T) {
+		ac := NewAccessControl(nil)
+		grant := Grant{GranteeKind: GranteeUser, GranteeID: alice.UserID, Role: RoleViewer, ResourceKind: ResourceBlock, ResourceID: a1.ID}
+		assert.NoError(t, ac.Grant(grant))
+
+		grant.Role = RoleOwner
+		assert.NoError(t, ac.Grant(grant))
+		assert.Equal(t, []Grant{grant}, ac.Grants(ResourceBlock, a1.ID))
+		assert.True(t, ac.Can(alice, ActionShare, a1).Allowed)
+
+		assert.True(t, ac.Revoke(ResourceBlock, a1.ID, GranteeUser, alice.UserID))
+		assert.False(t, ac.Revoke(ResourceBlock, a1.ID, GranteeUser, alice.UserID))
+		assert.False(t, ac.Can(alice, ActionView, a1).Allowed)
+
+		assert.Error(t, ac.Grant(Grant{GranteeKind: GranteeUser, Role: "admin", ResourceKind: ResourceBlock}))
+		assert.Error(t, ac.Grant(Grant{GranteeKind: "robot", Role: RoleViewer, ResourceKind: ResourceBlock}))
+		assert.Error(t, ac.Grant(Grant{GranteeKind: GranteeUser, Role: RoleViewer, ResourceKind: "folder"}))
+	})
+}
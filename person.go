@@ -168,3 +168,14 @@ func GetPersonProperties() []string {
 		PropertyKeyDescription,
 	}
 }
+
+func init() {
+	mustRegisterType(TypeDefinition{
+		DataType:     TypePerson,
+		RenderFunc:   renderWith(RenderPersonProperties),
+		PropertyKeys: GetPersonProperties(),
+		Content:      BlockContentTypeStructural,
+		Final:        true,
+		Schema:       NewPropertySchema(GetPersonProperties()...).Require(PropertyKeyFirstName),
+	})
+}
@@ -345,3 +345,16 @@ func GetPlaceProperties() []string {
 func GenerateGoogleMapsURL(latitude, longitude float64) string {
 	return fmt.Sprintf("https://www.google.com/maps?q=%f,%f", latitude, longitude)
 }
+
+func init() {
+	mustRegisterType(TypeDefinition{
+		DataType:     TypePlace,
+		RenderFunc:   renderWith(RenderPlaceProperties),
+		PropertyKeys: GetPlaceProperties(),
+		Content:      BlockContentTypeStructural,
+		Final:        true,
+		Schema: NewPropertySchema(GetPlaceProperties()...).
+			Require(PropertyKeyTitle).
+			WithCardinality(PropertyKeyCoordinates, 2, 2),
+	})
+}
@@ -0,0 +1,411 @@
+package blocks
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ValidationCode categorizes a validation problem
+type ValidationCode string
+
+func (c ValidationCode) String() string {
+	return string(c)
+}
+
+const (
+	ValidationCodeUnknownType        ValidationCode = "unknown_type"
+	ValidationCodeMissingRequired    ValidationCode = "missing_required"
+	ValidationCodeUnknownProperty    ValidationCode = "unknown_property"
+	ValidationCodeInvalidType        ValidationCode = "invalid_type"
+	ValidationCodeInvalidEnum        ValidationCode = "invalid_enum"
+	ValidationCodeInvalidCardinality ValidationCode = "invalid_cardinality"
+	ValidationCodeInvalid            ValidationCode = "invalid"
+)
+
+// ValidationError describes a single problem found while validating a block.
+// Path points to the offending value, e.g. "type", "properties.title" or "properties.genres[2]".
+type ValidationError struct {
+	Path    string         `json:"path"`
+	Code    ValidationCode `json:"code"`
+	Message string         `json:"message"`
+
+	// Err is the underlying error returned by a custom validator, if any
+	Err error `json:"-"`
+}
+
+func (e ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+func (e ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationErrors is a list of validation problems that can be returned as a single error
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, validationError := range e {
+		messages = append(messages, validationError.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, validationError := range e {
+		errs = append(errs, validationError)
+	}
+	return errs
+}
+
+// PropertySpec declares how a single property of a block type must look
+type PropertySpec struct {
+	Key      string
+	Type     PropertyType
+	Required bool
+
+	// Enum restricts string values to the given list when not empty
+	Enum []string
+
+	// MinValues and MaxValues restrict the number of values stored for the key.
+	// MaxValues of 0 means there is no upper limit.
+	MinValues int
+	MaxValues int
+}
+
+// PropertySchema declares the properties of a block type
+type PropertySchema struct {
+	Properties []PropertySpec
+
+	// AllowUnknown allows properties that are not declared in the schema
+	AllowUnknown bool
+}
+
+// NewPropertySchema creates a schema allowing the given keys.
+// Value types come from the global property types, scalar properties accept a single value
+// and array properties accept any number of values. All keys are optional.
+func NewPropertySchema(keys ...string) *PropertySchema {
+	schema := &PropertySchema{}
+	for _, key := range keys {
+		propType := getPropertyType(key)
+		spec := PropertySpec{Key: key, Type: propType}
+		switch propType {
+		case TypeStringArray, TypeFloatArray, TypeAny:
+			spec.MaxValues = 0
+		default:
+			spec.MaxValues = 1
+		}
+		schema.Properties = append(schema.Properties, spec)
+	}
+	return schema
+}
+
+// Require marks the given keys as required
+func (s *PropertySchema) Require(keys ...string) *PropertySchema {
+	for _, key := range keys {
+		if spec := s.spec(key); spec != nil {
+			spec.Required = true
+			if spec.MinValues < 1 {
+				spec.MinValues = 1
+			}
+		}
+	}
+	return s
+}
+
+// WithEnum restricts the values of the given key
+func (s *PropertySchema) WithEnum(key string, values ...string) *PropertySchema {
+	if spec := s.spec(key); spec != nil {
+		spec.Enum = values
+	}
+	return s
+}
+
+// WithCardinality sets the allowed number of values of the given key
+func (s *PropertySchema) WithCardinality(key string, min, max int) *PropertySchema {
+	if spec := s.spec(key); spec != nil {
+		spec.MinValues = min
+		spec.MaxValues = max
+	}
+	return s
+}
+
+// Spec returns the spec declared for the given key
+func (s *PropertySchema) Spec(key string) (PropertySpec, bool) {
+	if spec := s.spec(key); spec != nil {
+		return *spec, true
+	}
+	return PropertySpec{}, false
+}
+
+// RequiredKeys returns the keys that must be present
+func (s *PropertySchema) RequiredKeys() []string {
+	var keys []string
+	for _, spec := range s.Properties {
+		if spec.Required {
+			keys = append(keys, spec.Key)
+		}
+	}
+	return keys
+}
+
+func (s *PropertySchema) spec(key string) *PropertySpec {
+	for i := range s.Properties {
+		if s.Properties[i].Key == key {
+			return &s.Properties[i]
+		}
+	}
+	return nil
+}
+
+// Validate checks the properties against the schema and returns every problem found
+func (s *PropertySchema) Validate(p Properties) []ValidationError {
+	var errs []ValidationError
+
+	for _, spec := range s.Properties {
+		path := fmt.Sprintf("%s.%s", BlockPropertyProperties, spec.Key)
+		rawValues, exists := p[spec.Key]
+
+		if !exists || isEmptyValues(rawValues) {
+			if spec.Required {
+				errs = append(errs, ValidationError{
+					Path:    path,
+					Code:    ValidationCodeMissingRequired,
+					Message: "required property is missing",
+				})
+			}
+			continue
+		}
+
+		values := rawValues
+		if spec.Type == TypeStringArray || spec.Type == TypeFloatArray {
+			values = flattenValues(rawValues)
+		}
+
+		if len(values) < spec.MinValues || (spec.MaxValues > 0 && len(values) > spec.MaxValues) {
+			errs = append(errs, ValidationError{
+				Path:    path,
+				Code:    ValidationCodeInvalidCardinality,
+				Message: fmt.Sprintf("expected %s, got %d", describeCardinality(spec.MinValues, spec.MaxValues), len(values)),
+			})
+		}
+
+		for i, value := range values {
+			valuePath := fmt.Sprintf("%s[%d]", path, i)
+			if !isValueOfType(spec.Type, value) {
+				errs = append(errs, ValidationError{
+					Path:    valuePath,
+					Code:    ValidationCodeInvalidType,
+					Message: fmt.Sprintf("value of type %T is not a valid %s", value, spec.Type),
+				})
+				continue
+			}
+			if len(spec.Enum) > 0 && !isOneOf(spec.Enum, fmt.Sprintf("%v", value)) {
+				errs = append(errs, ValidationError{
+					Path:    valuePath,
+					Code:    ValidationCodeInvalidEnum,
+					Message: fmt.Sprintf("value %v is not one of %s", value, strings.Join(spec.Enum, ", ")),
+				})
+			}
+		}
+	}
+
+	if !s.AllowUnknown {
+		unknownKeys := make([]string, 0)
+		for key := range p {
+			if s.spec(key) == nil {
+				unknownKeys = append(unknownKeys, key)
+			}
+		}
+		sort.Strings(unknownKeys)
+		for _, key := range unknownKeys {
+			errs = append(errs, ValidationError{
+				Path:    fmt.Sprintf("%s.%s", BlockPropertyProperties, key),
+				Code:    ValidationCodeUnknownProperty,
+				Message: "property is not allowed for this type",
+			})
+		}
+	}
+
+	return errs
+}
+
+// SchemaProvider is implemented by type handlers that declare a property schema
+type SchemaProvider interface {
+	PropertySchema() *PropertySchema
+}
+
+// Validate validates a whole block against the TypeHandler registered for its type,
+// including its property schema, and returns every problem found.
+// It returns nil when the block is valid.
+func Validate(b Block) []ValidationError {
+	h, ok := LookupType(b.Type)
+	if !ok {
+		return []ValidationError{{
+			Path:    BlockPropertyType,
+			Code:    ValidationCodeUnknownType,
+			Message: fmt.Sprintf("type %q is not registered", b.Type),
+		}}
+	}
+
+	var errs []ValidationError
+	if provider, ok := h.(SchemaProvider); ok {
+		if schema := provider.PropertySchema(); schema != nil {
+			errs = append(errs, schema.Validate(b.Properties)...)
+		}
+	}
+
+	return append(errs, toValidationErrors(h.Validate(b))...)
+}
+
+// toValidationErrors converts an error returned by a TypeHandler into validation errors
+func toValidationErrors(err error) []ValidationError {
+	if err == nil {
+		return nil
+	}
+
+	var validationErrors ValidationErrors
+	if errors.As(err, &validationErrors) {
+		return validationErrors
+	}
+
+	var validationError ValidationError
+	if errors.As(err, &validationError) {
+		return []ValidationError{validationError}
+	}
+
+	return []ValidationError{{
+		Code:    ValidationCodeInvalid,
+		Message: err.Error(),
+		Err:     err,
+	}}
+}
+
+// isEmptyValues reports whether the values contain nothing but nils and empty strings
+func isEmptyValues(values []interface{}) bool {
+	for _, value := range values {
+		switch v := value.(type) {
+		case nil:
+			continue
+		case string:
+			if v != "" {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// flattenValues flattens nested arrays, which are produced when an array is stored with ReplaceValue
+func flattenValues(values []interface{}) []interface{} {
+	var result []interface{}
+	for _, value := range values {
+		switch v := value.(type) {
+		case []interface{}:
+			result = append(result, flattenValues(v)...)
+		case []string:
+			for _, s := range v {
+				result = append(result, s)
+			}
+		case []float64:
+			for _, f := range v {
+				result = append(result, f)
+			}
+		default:
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// isValueOfType checks whether a single value is acceptable for a property type
+func isValueOfType(t PropertyType, value interface{}) bool {
+	switch t {
+	case TypeString:
+		_, ok := value.(string)
+		return ok
+	case TypeInt:
+		switch v := value.(type) {
+		case int, int32, int64, time.Duration:
+			return true
+		case float64:
+			return v == float64(int64(v))
+		}
+		return false
+	case TypeFloat, TypeFloatArray:
+		switch value.(type) {
+		case float64, float32, int, int32, int64:
+			return true
+		}
+		return false
+	case TypeBool:
+		_, ok := value.(bool)
+		return ok
+	case TypeDateTime:
+		switch v := value.(type) {
+		case time.Time:
+			return true
+		case string:
+			_, ok := parseTimeString(v)
+			return ok
+		}
+		return false
+	case TypeStringArray:
+		_, ok := value.(string)
+		return ok
+	default:
+		return true
+	}
+}
+
+func describeCardinality(min, max int) string {
+	switch {
+	case max == 0:
+		return fmt.Sprintf("at least %d value(s)", min)
+	case min == max:
+		return fmt.Sprintf("exactly %d value(s)", min)
+	default:
+		return fmt.Sprintf("between %d and %d value(s)", min, max)
+	}
+}
+
+func isOneOf(values []string, s string) bool {
+	for _, value := range values {
+		if value == s {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the name of the property type
+func (t PropertyType) String() string {
+	switch t {
+	case TypeString:
+		return "string"
+	case TypeInt:
+		return "int"
+	case TypeFloat:
+		return "float"
+	case TypeBool:
+		return "bool"
+	case TypeDateTime:
+		return "datetime"
+	case TypeStringArray:
+		return "string array"
+	case TypeFloatArray:
+		return "float array"
+	default:
+		return "any"
+	}
+}
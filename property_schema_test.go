@@ -0,0 +1,132 @@
+package blocks
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	strPtr := func(s string) *string {
+		return &s
+	}
+
+	t.Run("valid movie built with AddMovieProperties", func(t *testing.T) {
+		block := NewEmptyBlock()
+		block.Type = TypeMovie
+		year := 1999
+		genres := []string{"Action", "Sci-Fi"}
+		err := AddMovieProperties(&block, strPtr("The Matrix"), nil, nil, nil, nil, nil, &year,
+			strPtr("8.7"), strPtr("136"), nil, nil, nil, &genres, nil, nil, nil, true)
+		assert.NoError(t, err)
+
+		assert.Empty(t, Validate(block))
+	})
+
+	t.Run("unknown type", func(t *testing.T) {
+		block := NewEmptyBlock()
+		block.Type = DataType("unknown")
+
+		errs := Validate(block)
+		assert.Len(t, errs, 1)
+		assert.Equal(t, ValidationCodeUnknownType, errs[0].Code)
+		assert.Equal(t, BlockPropertyType, errs[0].Path)
+	})
+
+	t.Run("reports every problem with a path", func(t *testing.T) {
+		block := NewEmptyBlock()
+		block.Type = TypeMovie
+		block.Properties = Properties{
+			PropertyKeyReleaseYear: {"nineteen ninety-nine"},
+			PropertyKeyGenres:      {"Action", 42},
+			PropertyKeyRating:      {8.7, 9.1},
+			"unexpected":           {"value"},
+		}
+
+		errs := Validate(block)
+		codes := map[string]ValidationCode{}
+		for _, e := range errs {
+			codes[e.Path] = e.Code
+		}
+
+		assert.Equal(t, ValidationCodeMissingRequired, codes["properties.title"])
+		assert.Equal(t, ValidationCodeInvalidType, codes["properties.release_year[0]"])
+		assert.Equal(t, ValidationCodeInvalidType, codes["properties.genres[1]"])
+		assert.Equal(t, ValidationCodeInvalidCardinality, codes["properties.rating"])
+		assert.Equal(t, ValidationCodeUnknownProperty, codes["properties.unexpected"])
+		assert.Len(t, errs, 5)
+	})
+
+	t.Run("email requires id and sender", func(t *testing.T) {
+		block := NewEmptyBlock()
+		block.Type = TypeEmail
+		date := time.Date(2023, 7, 20, 15, 45, 0, 0, time.UTC)
+		err := AddEmailProperties(&block, nil, nil, nil, strPtr("to@example.com"), strPtr("Hello"), nil, &date, nil, nil, nil)
+		assert.NoError(t, err)
+
+		errs := Validate(block)
+		assert.Len(t, errs, 2)
+		assert.Equal(t, "properties.email_id", errs[0].Path)
+		assert.Equal(t, "properties.from", errs[1].Path)
+	})
+
+	t.Run("place coordinates must have two values", func(t *testing.T) {
+		block := NewEmptyBlock()
+		block.Type = TypePlace
+		block.Properties = Properties{
+			PropertyKeyTitle:       {"Home"},
+			PropertyKeyCoordinates: {[]float64{52.52}},
+		}
+
+		errs := Validate(block)
+		assert.Len(t, errs, 1)
+		assert.Equal(t, ValidationCodeInvalidCardinality, errs[0].Code)
+	})
+
+	t.Run("enum values", func(t *testing.T) {
+		block := NewEmptyBlock()
+		block.Type = TypeYouTube
+		block.Properties = Properties{
+			PropertyKeyURL:        {"https://youtube.com/watch?v=1"},
+			PropertyKeyDefinition: {"4k"},
+		}
+
+		errs := Validate(block)
+		assert.Len(t, errs, 1)
+		assert.Equal(t, ValidationCodeInvalidEnum, errs[0].Code)
+		assert.Equal(t, "properties.definition[0]", errs[0].Path)
+	})
+
+	t.Run("fragments allow any property", func(t *testing.T) {
+		block := NewEmptyBlock()
+		block.Properties = Properties{"anything": {"goes"}}
+
+		assert.Empty(t, Validate(block))
+	})
+
+	t.Run("custom validator errors are included", func(t *testing.T) {
+		custom := DataType("validated")
+		errCustom := errors.New("custom rule failed")
+		err := RegisterType(TypeDefinition{
+			DataType:     custom,
+			PropertyKeys: []string{PropertyKeyTitle},
+			Schema:       NewPropertySchema(PropertyKeyTitle).Require(PropertyKeyTitle),
+			ValidateFunc: func(b Block) error {
+				return errCustom
+			},
+		})
+		assert.NoError(t, err)
+		defer UnregisterType(custom)
+
+		block := NewEmptyBlock()
+		block.Type = custom
+
+		errs := Validate(block)
+		assert.Len(t, errs, 2)
+		assert.Equal(t, ValidationCodeMissingRequired, errs[0].Code)
+		assert.Equal(t, ValidationCodeInvalid, errs[1].Code)
+		assert.ErrorIs(t, ValidationErrors(errs), errCustom)
+	})
+}
@@ -0,0 +1,54 @@
+package blocks
+
+import (
+	"fmt"
+	"strings"
+)
+
+func AddQuoteProperties(b *Block, title *string) error {
+	if b == nil {
+		return fmt.Errorf("cannot add quote properties because given b is nil")
+	}
+
+	if title != nil {
+		if err := b.Properties.ReplaceValue(PropertyKeyTitle, *title); err != nil {
+			return fmt.Errorf("failed to set title property: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// RenderQuoteProperties renders a quote block as a markdown blockquote, prefixing every line
+func RenderQuoteProperties(b Block) string {
+	text, ok := b.Properties.GetString(PropertyKeyTitle)
+	if !ok {
+		return ""
+	}
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = ">"
+		} else {
+			lines[i] = "> " + line
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func GetQuoteProperties() []string {
+	return []string{
+		PropertyKeyTitle,
+	}
+}
+
+func init() {
+	mustRegisterType(TypeDefinition{
+		DataType:     TypeQuote,
+		RenderFunc:   renderWith(RenderQuoteProperties),
+		PropertyKeys: GetQuoteProperties(),
+		Content:      BlockContentTypeTextual,
+	})
+}
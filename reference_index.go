@@ -0,0 +1,199 @@
+package blocks
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// DanglingReason explains why a reference cannot be followed
+type DanglingReason string
+
+const (
+	// DanglingReasonMissing is used when the referenced block is not in the index
+	DanglingReasonMissing DanglingReason = "missing"
+	// DanglingReasonArchived is used when the referenced block is archived
+	DanglingReasonArchived DanglingReason = "archived"
+)
+
+// DanglingReference is a [[block:uuid]] annotation pointing to a missing or archived block
+type DanglingReference struct {
+	SourceID uuid.UUID      `json:"source_id"`
+	TargetID uuid.UUID      `json:"target_id"`
+	Reason   DanglingReason `json:"reason"`
+}
+
+// ReferenceIndex keeps the forward links and backlinks between blocks created by [[block:uuid]] annotations
+// in text properties and RawBody. It is safe for concurrent use.
+type ReferenceIndex struct {
+	mu       sync.RWMutex
+	statuses map[uuid.UUID]LifecycleStatus
+	forward  map[uuid.UUID][]uuid.UUID
+	backward map[uuid.UUID]map[uuid.UUID]bool
+}
+
+// NewReferenceIndex builds an index over the given blocks
+func NewReferenceIndex(blocks []Block) *ReferenceIndex {
+	index := &ReferenceIndex{
+		statuses: make(map[uuid.UUID]LifecycleStatus, len(blocks)),
+		forward:  make(map[uuid.UUID][]uuid.UUID, len(blocks)),
+		backward: make(map[uuid.UUID]map[uuid.UUID]bool),
+	}
+	for _, b := range blocks {
+		index.update(b)
+	}
+	return index
+}
+
+// Update adds the block to the index or replaces its previous version
+func (i *ReferenceIndex) Update(b Block) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.update(b)
+}
+
+func (i *ReferenceIndex) update(b Block) {
+	i.unlink(b.ID)
+	i.statuses[b.ID] = b.LifecycleStatus
+
+	references := BlockReferences(b)
+	if len(references) == 0 {
+		return
+	}
+	i.forward[b.ID] = references
+	for _, targetID := range references {
+		if i.backward[targetID] == nil {
+			i.backward[targetID] = make(map[uuid.UUID]bool)
+		}
+		i.backward[targetID][b.ID] = true
+	}
+}
+
+// Remove removes the block and its forward links from the index.
+// References from other blocks to it are kept and reported as dangling.
+func (i *ReferenceIndex) Remove(id uuid.UUID) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.unlink(id)
+	delete(i.statuses, id)
+}
+
+// unlink removes the forward links of the block and the matching backlinks
+func (i *ReferenceIndex) unlink(id uuid.UUID) {
+	for _, targetID := range i.forward[id] {
+		delete(i.backward[targetID], id)
+		if len(i.backward[targetID]) == 0 {
+			delete(i.backward, targetID)
+		}
+	}
+	delete(i.forward, id)
+}
+
+// References returns the blocks referenced by the block, in order of first appearance
+func (i *ReferenceIndex) References(id uuid.UUID) []uuid.UUID {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return append([]uuid.UUID{}, i.forward[id]...)
+}
+
+// Backlinks returns the blocks referencing the block, sorted by ID
+func (i *ReferenceIndex) Backlinks(id uuid.UUID) []uuid.UUID {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	backlinks := make([]uuid.UUID, 0, len(i.backward[id]))
+	for sourceID := range i.backward[id] {
+		backlinks = append(backlinks, sourceID)
+	}
+	sortIDs(backlinks)
+	return backlinks
+}
+
+// DanglingReferences returns every reference to a block that is missing from the index or archived,
+// sorted by source and target ID
+func (i *ReferenceIndex) DanglingReferences() []DanglingReference {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	var dangling []DanglingReference
+	for sourceID, targets := range i.forward {
+		for _, targetID := range targets {
+			status, exists := i.statuses[targetID]
+			switch {
+			case !exists:
+				dangling = append(dangling, DanglingReference{SourceID: sourceID, TargetID: targetID, Reason: DanglingReasonMissing})
+			case status == LifecycleStatusArchived:
+				dangling = append(dangling, DanglingReference{SourceID: sourceID, TargetID: targetID, Reason: DanglingReasonArchived})
+			}
+		}
+	}
+
+	sort.Slice(dangling, func(a, b int) bool {
+		if dangling[a].SourceID != dangling[b].SourceID {
+			return dangling[a].SourceID.String() < dangling[b].SourceID.String()
+		}
+		return dangling[a].TargetID.String() < dangling[b].TargetID.String()
+	})
+	return dangling
+}
+
+// BlockReferences returns the blocks referenced from the text properties and RawBody of the block,
+// in order of first appearance. Properties are scanned in key order and references to the block itself are ignored.
+func BlockReferences(b Block) []uuid.UUID {
+	seen := map[uuid.UUID]bool{b.ID: true}
+	var references []uuid.UUID
+
+	add := func(text string) {
+		for _, id := range ExtractReferences(text) {
+			if !seen[id] {
+				seen[id] = true
+				references = append(references, id)
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(b.Properties))
+	for key := range b.Properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, value := range b.Properties[key] {
+			visitTextValues(value, add)
+		}
+	}
+	add(b.RawBody)
+
+	return references
+}
+
+// visitTextValues calls visit for every string held by a property value
+func visitTextValues(value interface{}, visit func(string)) {
+	switch v := value.(type) {
+	case string:
+		visit(v)
+	case []string:
+		for _, item := range v {
+			visit(item)
+		}
+	case []interface{}:
+		for _, item := range v {
+			visitTextValues(item, visit)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			visitTextValues(v[key], visit)
+		}
+	}
+}
+
+func sortIDs(ids []uuid.UUID) {
+	sort.Slice(ids, func(a, b int) bool { return ids[a].String() < ids[b].String() })
+}
@@ -0,0 +1,52 @@
+package blocks
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReferenceIndex(t *testing.T) {
+	page, note, archived := NewEmptyBlock(), NewEmptyBlock(), NewEmptyBlock()
+	archived.LifecycleStatus = LifecycleStatusArchived
+	missing := uuid.New()
+
+	page.Properties[PropertyKeyTitle] = []interface{}{"see " + note.AnnotationID() + " and " + page.AnnotationID()}
+	page.RawBody = "<p>" + archived.AnnotationID() + note.AnnotationID() + "</p>"
+	note.Properties[PropertyKeyGenres] = []interface{}{[]string{page.AnnotationID(), "[[block:" + missing.String() + "]]"}}
+
+	index := NewReferenceIndex([]Block{page, note, archived})
+
+	t.Run("forward links and backlinks", func(t *testing.T) {
+		assert.Equal(t, []uuid.UUID{note.ID, archived.ID}, index.References(page.ID))
+		assert.Equal(t, []uuid.UUID{page.ID, missing}, index.References(note.ID))
+		assert.Equal(t, []uuid.UUID{page.ID}, index.Backlinks(note.ID))
+		assert.Equal(t, []uuid.UUID{note.ID}, index.Backlinks(page.ID))
+		assert.Empty(t, index.Backlinks(uuid.New()))
+	})
+
+	t.Run("dangling references", func(t *testing.T) {
+		assert.ElementsMatch(t, []DanglingReference{
+			{SourceID: page.ID, TargetID: archived.ID, Reason: DanglingReasonArchived},
+			{SourceID: note.ID, TargetID: missing, Reason: DanglingReasonMissing},
+		}, index.DanglingReferences())
+	})
+
+	t.Run("incremental updates", func(t *testing.T) {
+		updated := page.Copy()
+		updated.RawBody = ""
+		index.Update(updated)
+
+		assert.Equal(t, []uuid.UUID{note.ID}, index.References(page.ID))
+		assert.Empty(t, index.Backlinks(archived.ID))
+
+		index.Remove(note.ID)
+		assert.Empty(t, index.References(note.ID))
+		assert.Empty(t, index.Backlinks(page.ID))
+		assert.Equal(t, []uuid.UUID{page.ID}, index.Backlinks(note.ID))
+		assert.Equal(t, []DanglingReference{
+			{SourceID: page.ID, TargetID: note.ID, Reason: DanglingReasonMissing},
+		}, index.DanglingReferences())
+	})
+}
@@ -0,0 +1,406 @@
+package blocks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Revision is a snapshot of a block at a given version, together with the changes from the previous revision.
+// Changes is empty for the first revision of a block.
+type Revision struct {
+	BlockID   uuid.UUID `json:"block_id"`
+	Version   int64     `json:"version"`
+	Snapshot  Block     `json:"snapshot"`
+	Changes   ChangeSet `json:"changes"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RevisionStore persists the revisions of blocks
+type RevisionStore interface {
+	// Save stores a new revision. Its version must be greater than the latest version stored for the block.
+	Save(ctx context.Context, revision Revision) error
+
+	// Get returns the revision of the block with the given version
+	Get(ctx context.Context, blockID uuid.UUID, version int64) (Revision, error)
+
+	// Latest returns the most recent revision of the block
+	Latest(ctx context.Context, blockID uuid.UUID) (Revision, error)
+
+	// List returns all revisions of the block, oldest first
+	List(ctx context.Context, blockID uuid.UUID) ([]Revision, error)
+}
+
+// InMemoryRevisionStore is a RevisionStore keeping all revisions in memory.
+// It is safe for concurrent use.
+type InMemoryRevisionStore struct {
+	mu        sync.RWMutex
+	revisions map[uuid.UUID][]Revision
+}
+
+// NewInMemoryRevisionStore creates an empty in-memory revision store
+func NewInMemoryRevisionStore() *InMemoryRevisionStore {
+	return &InMemoryRevisionStore{revisions: make(map[uuid.UUID][]Revision)}
+}
+
+func (s *InMemoryRevisionStore) Save(_ context.Context, revision Revision) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	revisions := s.revisions[revision.BlockID]
+	if len(revisions) > 0 && revision.Version <= revisions[len(revisions)-1].Version {
+		return fmt.Errorf("%w: block %s version %d", ErrRevisionOutOfOrder, revision.BlockID, revision.Version)
+	}
+
+	revision.Snapshot = revision.Snapshot.Copy()
+	s.revisions[revision.BlockID] = append(revisions, revision)
+	return nil
+}
+
+func (s *InMemoryRevisionStore) Get(_ context.Context, blockID uuid.UUID, version int64) (Revision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, revision := range s.revisions[blockID] {
+		if revision.Version == version {
+			return copyRevision(revision), nil
+		}
+	}
+	return Revision{}, fmt.Errorf("%w: block %s version %d", ErrRevisionNotFound, blockID, version)
+}
+
+func (s *InMemoryRevisionStore) Latest(_ context.Context, blockID uuid.UUID) (Revision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	revisions := s.revisions[blockID]
+	if len(revisions) == 0 {
+		return Revision{}, fmt.Errorf("%w: block %s", ErrRevisionNotFound, blockID)
+	}
+	return copyRevision(revisions[len(revisions)-1]), nil
+}
+
+func (s *InMemoryRevisionStore) List(_ context.Context, blockID uuid.UUID) ([]Revision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	revisions := make([]Revision, 0, len(s.revisions[blockID]))
+	for _, revision := range s.revisions[blockID] {
+		revisions = append(revisions, copyRevision(revision))
+	}
+	return revisions, nil
+}
+
+func copyRevision(revision Revision) Revision {
+	revision.Snapshot = revision.Snapshot.Copy()
+	return revision
+}
+
+// RecordRevision stores the current state of the block as a new revision.
+// The version of the block is kept if it is newer than the latest revision, e.g. after CompareAndSwap,
+// and increased otherwise. If nothing but the version and UpdatedAt changed since the latest revision,
+// the latest revision is returned and the block is left untouched.
+// The revision time is the UpdatedAt of the block, or now if it is not set.
+func RecordRevision(ctx context.Context, store RevisionStore, b *Block) (Revision, error) {
+	latest, err := store.Latest(ctx, b.ID)
+	hasLatest := err == nil
+	if err != nil && !errors.Is(err, ErrRevisionNotFound) {
+		return Revision{}, err
+	}
+
+	snapshot := b.Copy()
+	switch {
+	case hasLatest && latest.Version >= snapshot.Version:
+		snapshot.Version = latest.Version + 1
+	case snapshot.Version == 0:
+		snapshot.Version = 1
+	}
+
+	revision := Revision{
+		BlockID:   b.ID,
+		Version:   snapshot.Version,
+		Snapshot:  snapshot,
+		Changes:   ChangeSet{BlockID: b.ID},
+		CreatedAt: b.UpdatedAt,
+	}
+	if revision.CreatedAt.IsZero() {
+		revision.CreatedAt = time.Now()
+	}
+
+	if hasLatest {
+		revision.Changes = DiffBlocks(latest.Snapshot, snapshot)
+		if !hasContentChanges(revision.Changes) {
+			return latest, nil
+		}
+	}
+
+	if err := store.Save(ctx, revision); err != nil {
+		return Revision{}, err
+	}
+	b.Version = revision.Version
+	return revision, nil
+}
+
+// hasContentChanges reports whether the change set contains more than version and timestamp bookkeeping
+func hasContentChanges(changes ChangeSet) bool {
+	if len(changes.Properties) > 0 || len(changes.Styles) > 0 {
+		return true
+	}
+	for _, change := range changes.Fields {
+		if change.Field != BlockPropertyVersion && change.Field != BlockPropertyUpdatedAt {
+			return true
+		}
+	}
+	return false
+}
+
+// RevisionAt returns the latest revision of the block recorded at or before the given time
+func RevisionAt(ctx context.Context, store RevisionStore, blockID uuid.UUID, at time.Time) (Revision, error) {
+	revisions, err := store.List(ctx, blockID)
+	if err != nil {
+		return Revision{}, err
+	}
+	for i := len(revisions) - 1; i >= 0; i-- {
+		if !revisions[i].CreatedAt.After(at) {
+			return revisions[i], nil
+		}
+	}
+	return Revision{}, fmt.Errorf("%w: block %s at %s", ErrRevisionNotFound, blockID, at.Format(time.RFC3339))
+}
+
+// DiffRevisions returns the changes between two revisions of a block
+func DiffRevisions(ctx context.Context, store RevisionStore, blockID uuid.UUID, fromVersion, toVersion int64) (ChangeSet, error) {
+	from, err := store.Get(ctx, blockID, fromVersion)
+	if err != nil {
+		return ChangeSet{}, err
+	}
+	to, err := store.Get(ctx, blockID, toVersion)
+	if err != nil {
+		return ChangeSet{}, err
+	}
+	return DiffBlocks(from.Snapshot, to.Snapshot), nil
+}
+
+// RevertBlock restores the data of the block (type, properties, styles, raw body, metadata, meaning,
+// classification and generated content) from an earlier version and records the result as a new revision.
+// Identity, hierarchy, placement and processing fields are kept, so the block stays where it is.
+func RevertBlock(ctx context.Context, store RevisionStore, b *Block, version int64) (Revision, error) {
+	target, err := store.Get(ctx, b.ID, version)
+	if err != nil {
+		return Revision{}, err
+	}
+
+	reverted := restoreBlockData(*b, target.Snapshot)
+	reverted.UpdatedAt = time.Now()
+
+	revision, err := RecordRevision(ctx, store, &reverted)
+	if err != nil {
+		return Revision{}, err
+	}
+	*b = reverted
+	return revision, nil
+}
+
+// RevertSubtree reverts a block and its descendants to their state at the given time.
+// The block keeps its current placement; its Content and the descendants are restored from their revisions,
+// recreating descendants that were deleted since. Current descendants of the restored blocks that did not
+// belong to the subtree at that time are detached and archived together with their own descendants, and
+// restored descendants that have moved elsewhere since are taken out of their current parent. Hierarchy fields of the subtree and its ancestors are
+// recomputed, a revision is recorded for every changed block, and the tree is updated. The changed blocks are
+// returned for persistence.
+//
+// The operation is not atomic: if recording a revision fails, the revisions recorded before remain in the store
+// while the tree is left unchanged.
+func RevertSubtree(ctx context.Context, store RevisionStore, tree *BlockTree, blockID uuid.UUID, at time.Time) ([]Block, error) {
+	current, exists := tree.Get(blockID)
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrBlockNotFound, blockID)
+	}
+
+	rootRevision, err := RevisionAt(ctx, store, blockID, at)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	rootID := blockID
+	if current.RootParentID != nil {
+		rootID = *current.RootParentID
+	}
+
+	root := restoreBlockData(current, rootRevision.Snapshot)
+	root.Content = append([]uuid.UUID{}, rootRevision.Snapshot.Content...)
+
+	reverted := map[uuid.UUID]*Block{blockID: &root}
+	order := []uuid.UUID{blockID}
+	content := make(map[uuid.UUID][]uuid.UUID)
+
+	// Restore the descendants breadth-first from the restored Content lists
+	for i := 0; i < len(order); i++ {
+		parent := reverted[order[i]]
+
+		var children []uuid.UUID
+		for _, childID := range parent.Content {
+			if _, seen := reverted[childID]; seen {
+				continue
+			}
+
+			var child Block
+			revision, err := RevisionAt(ctx, store, childID, at)
+			if err != nil && !errors.Is(err, ErrRevisionNotFound) {
+				return nil, err
+			}
+			existing, inTree := tree.Get(childID)
+			switch {
+			case err == nil && inTree:
+				child = restoreBlockData(existing, revision.Snapshot)
+				child.Content = append([]uuid.UUID{}, revision.Snapshot.Content...)
+			case err == nil:
+				child = revision.Snapshot.Copy()
+				child.Version = 0
+			case inTree:
+				child = existing.Copy()
+			default:
+				// The child has neither been recorded nor does it exist anymore
+				continue
+			}
+
+			child.ParentID = copyIDPointer(&parent.ID)
+			child.RootParentID = copyIDPointer(&rootID)
+			child.SpaceID = current.SpaceID
+			reverted[childID] = &child
+			order = append(order, childID)
+			children = append(children, childID)
+		}
+		parent.Content = children
+		content[parent.ID] = children
+	}
+
+	for _, id := range order {
+		b := reverted[id]
+		b.ChildrenRecursive = collectDescendantIDs(id, content)
+		b.UpdatedAt = now
+	}
+
+	oldSubtree := append([]uuid.UUID{blockID}, tree.descendantIDs(blockID)...)
+
+	edited := make(map[uuid.UUID]*Block)
+	var editOrder []uuid.UUID
+	edit := func(id uuid.UUID) *Block {
+		if b, ok := reverted[id]; ok {
+			return b
+		}
+		if b, ok := edited[id]; ok {
+			return b
+		}
+		existing, _ := tree.Get(id)
+		b := existing.Copy()
+		b.UpdatedAt = now
+		edited[id] = &b
+		editOrder = append(editOrder, id)
+		return &b
+	}
+
+	// Current descendants of the restored blocks that were not part of the subtree at that time are detached,
+	// including the children that a restored block gained after it moved out of the subtree
+	var detachedIDs []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+	for _, id := range order {
+		for _, descendantID := range tree.descendantIDs(id) {
+			if _, kept := reverted[descendantID]; !kept && !seen[descendantID] {
+				seen[descendantID] = true
+				detachedIDs = append(detachedIDs, descendantID)
+			}
+		}
+	}
+
+	// Restored blocks that have since moved out of the subtree leave their current parent and its ancestors
+	for _, id := range order[1:] {
+		parentID, exists := tree.parents[id]
+		if !exists || containsID(order, parentID) {
+			continue
+		}
+		parent := edit(parentID)
+		parent.Content = removeIDs(parent.Content, []uuid.UUID{id})
+		// The block leaves with its whole current subtree
+		leaving := append([]uuid.UUID{id}, tree.descendantIDs(id)...)
+		for _, ancestorID := range append([]uuid.UUID{parentID}, tree.ancestorIDs(parentID)...) {
+			if _, restored := reverted[ancestorID]; restored {
+				continue
+			}
+			ancestor := edit(ancestorID)
+			ancestor.ChildrenRecursive = removeIDs(removeIDs(ancestor.ChildrenRecursive, order), leaving)
+		}
+	}
+
+	// Detach and archive the blocks; they keep their own hierarchy and only the topmost ones lose their parent
+	for _, id := range detachedIDs {
+		detached := edit(id)
+		detached.Content = removeIDs(detached.Content, order)
+		detached.ChildrenRecursive = removeIDs(detached.ChildrenRecursive, order)
+		detached.LifecycleStatus = LifecycleStatusArchived
+
+		topID := id
+		for {
+			parentID, exists := tree.parents[topID]
+			if !exists || !containsID(detachedIDs, parentID) {
+				break
+			}
+			topID = parentID
+		}
+		if topID == id {
+			detached.ParentID = nil
+			detached.RootParentID = nil
+		} else {
+			detached.RootParentID = copyIDPointer(&topID)
+		}
+	}
+
+	// The ancestors now contain the restored subtree
+	for _, ancestorID := range tree.ancestorIDs(blockID) {
+		ancestor := edit(ancestorID)
+		ancestor.ChildrenRecursive = append(removeIDs(ancestor.ChildrenRecursive, append(oldSubtree, order...)), order...)
+	}
+
+	changed := make([]*Block, 0, len(order)+len(editOrder))
+	for _, id := range order {
+		changed = append(changed, reverted[id])
+	}
+	for _, id := range editOrder {
+		changed = append(changed, edited[id])
+	}
+
+	// Every block is computed before the first revision is recorded
+	result := make([]Block, 0, len(changed))
+	for _, b := range changed {
+		if _, err := RecordRevision(ctx, store, b); err != nil {
+			return nil, fmt.Errorf("failed to record revision of block %s, the tree was not updated: %w", b.ID, err)
+		}
+		result = append(result, *b)
+	}
+	tree.putAll(result)
+
+	return result, nil
+}
+
+// restoreBlockData returns the current block with the data fields of the snapshot
+func restoreBlockData(current, snapshot Block) Block {
+	restored := current.Copy()
+	snapshot = snapshot.Copy()
+
+	restored.Type = snapshot.Type
+	restored.Properties = snapshot.Properties
+	restored.Styles = snapshot.Styles
+	restored.RawBody = snapshot.RawBody
+	restored.Metadata = snapshot.Metadata
+	restored.Meaning = snapshot.Meaning
+	restored.Classification = snapshot.Classification
+	restored.CalculatedContent = snapshot.CalculatedContent
+	restored.DenseVector = snapshot.DenseVector
+
+	return restored
+}
@@ -0,0 +1,242 @@
+package blocks
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRevisions(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	setTitle := func(b *Block, title string, at time.Time) {
+		b.Properties[PropertyKeyTitle] = []interface{}{title}
+		b.UpdatedAt = at
+	}
+
+	t.Run("record, list and diff", func(t *testing.T) {
+		store := NewInMemoryRevisionStore()
+		b := NewEmptyBlock()
+
+		setTitle(&b, "Draft", start)
+		first, err := RecordRevision(ctx, store, &b)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), first.Version)
+		assert.Equal(t, int64(1), b.Version)
+		assert.True(t, first.Changes.IsEmpty())
+
+		setTitle(&b, "Final", start.Add(time.Hour))
+		second, err := RecordRevision(ctx, store, &b)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), second.Version)
+		assert.Equal(t, []string{PropertyKeyTitle}, second.Changes.PropertyKeys())
+
+		// Touching UpdatedAt alone does not create a revision
+		b.UpdatedAt = start.Add(2 * time.Hour)
+		unchanged, err := RecordRevision(ctx, store, &b)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), unchanged.Version)
+
+		revisions, err := store.List(ctx, b.ID)
+		assert.NoError(t, err)
+		assert.Len(t, revisions, 2)
+		assert.Equal(t, "Draft", revisions[0].Snapshot.Properties[PropertyKeyTitle][0])
+
+		diff, err := DiffRevisions(ctx, store, b.ID, 1, 2)
+		assert.NoError(t, err)
+		title, _ := diff.Property(PropertyKeyTitle)
+		assert.Equal(t, []interface{}{"Draft"}, title.Old)
+		assert.Equal(t, []interface{}{"Final"}, title.New)
+
+		_, err = DiffRevisions(ctx, store, b.ID, 1, 7)
+		assert.ErrorIs(t, err, ErrRevisionNotFound)
+
+		at, err := RevisionAt(ctx, store, b.ID, start.Add(30*time.Minute))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), at.Version)
+
+		_, err = RevisionAt(ctx, store, b.ID, start.Add(-time.Minute))
+		assert.ErrorIs(t, err, ErrRevisionNotFound)
+
+		err = store.Save(ctx, Revision{BlockID: b.ID, Version: 2})
+		assert.ErrorIs(t, err, ErrRevisionOutOfOrder)
+	})
+
+	t.Run("revert block", func(t *testing.T) {
+		store := NewInMemoryRevisionStore()
+		b := NewEmptyBlock()
+
+		setTitle(&b, "Draft", start)
+		_, _ = RecordRevision(ctx, store, &b)
+		setTitle(&b, "Final", start.Add(time.Hour))
+		parentID := uuid.New()
+		b.ParentID = &parentID
+		_, _ = RecordRevision(ctx, store, &b)
+
+		revision, err := RevertBlock(ctx, store, &b, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), revision.Version)
+		assert.Equal(t, int64(3), b.Version)
+		assert.Equal(t, "Draft", b.Properties[PropertyKeyTitle][0])
+		assert.Equal(t, &parentID, b.ParentID)
+
+		_, err = RevertBlock(ctx, store, &b, 42)
+		assert.ErrorIs(t, err, ErrRevisionNotFound)
+	})
+
+	t.Run("revert subtree", func(t *testing.T) {
+		store := NewInMemoryRevisionStore()
+		page, a, a1, a2, b := newTestTreeBlocks()
+		for _, block := range []*Block{&page, &a, &a1, &a2, &b} {
+			setTitle(block, "v1", start)
+			_, err := RecordRevision(ctx, store, block)
+			assert.NoError(t, err)
+		}
+
+		tree, err := NewBlockTree([]Block{page, a, a1, a2, b})
+		assert.NoError(t, err)
+
+		// Later: a1 is edited, a2 is deleted and a new child is added to a
+		later := start.Add(time.Hour)
+		setTitle(&a1, "v2", later)
+		_, _ = RecordRevision(ctx, store, &a1)
+		tree.Put(a1)
+		assert.True(t, tree.Remove(a2.ID))
+
+		added := a.CreateChild()
+		setTitle(&added, "new", later)
+		_, _ = RecordRevision(ctx, store, &added)
+		tree.Put(added)
+
+		updatedA, _ := tree.Get(a.ID)
+		updatedA.Content = []uuid.UUID{a1.ID, added.ID}
+		updatedA.ChildrenRecursive = []uuid.UUID{a1.ID, added.ID}
+		setTitle(&updatedA, "v2", later)
+		_, _ = RecordRevision(ctx, store, &updatedA)
+		tree.Put(updatedA)
+
+		changed, err := RevertSubtree(ctx, store, tree, a.ID, start.Add(30*time.Minute))
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []uuid.UUID{a.ID, a1.ID, a2.ID, added.ID, page.ID}, blockIDs(changed))
+
+		assert.Equal(t, []uuid.UUID{a1.ID, a2.ID}, blockIDs(tree.Children(a.ID)))
+		for _, id := range []uuid.UUID{a.ID, a1.ID, a2.ID} {
+			restored, ok := tree.Get(id)
+			assert.True(t, ok)
+			assert.Equal(t, "v1", restored.Properties[PropertyKeyTitle][0])
+		}
+
+		detached, _ := tree.Get(added.ID)
+		assert.Nil(t, detached.ParentID)
+		assert.Equal(t, LifecycleStatusArchived, detached.LifecycleStatus)
+
+		report, err := CheckIntegrity(tree.Blocks())
+		assert.NoError(t, err)
+		assert.False(t, report.HasIssues(), "%v", report.Issues)
+
+		latest, err := store.Latest(ctx, a.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), latest.Version)
+	})
+
+	t.Run("revert subtree restores children moved out and keeps detached hierarchy", func(t *testing.T) {
+		store := NewInMemoryRevisionStore()
+		page, a, a1, a2, b := newTestTreeBlocks()
+		for _, block := range []*Block{&page, &a, &a1, &a2, &b} {
+			setTitle(block, "v1", start)
+			_, err := RecordRevision(ctx, store, block)
+			assert.NoError(t, err)
+		}
+
+		tree, err := NewBlockTree([]Block{page, a, a1, a2, b})
+		assert.NoError(t, err)
+
+		// Later: a1 moves to b, and a new child with its own child is added to a
+		_, err = MoveSubtree(tree, a1.ID, DestinationTypeBlock, b.ID, MoveOptions{})
+		assert.NoError(t, err)
+
+		updatedA, _ := tree.Get(a.ID)
+		added := updatedA.CreateChild()
+		nested := added.CreateChild()
+		added.Content = []uuid.UUID{nested.ID}
+		added.ChildrenRecursive = []uuid.UUID{nested.ID}
+		updatedA.Content = append(updatedA.Content, added.ID)
+		updatedA.ChildrenRecursive = append(updatedA.ChildrenRecursive, added.ID, nested.ID)
+		updatedPage, _ := tree.Get(page.ID)
+		updatedPage.ChildrenRecursive = append(updatedPage.ChildrenRecursive, added.ID, nested.ID)
+		tree.putAll([]Block{updatedA, added, nested, updatedPage})
+
+		report, err := CheckIntegrity(tree.Blocks())
+		assert.NoError(t, err)
+		assert.False(t, report.HasIssues(), "%v", report.Issues)
+
+		changed, err := RevertSubtree(ctx, store, tree, a.ID, start.Add(30*time.Minute))
+		assert.NoError(t, err)
+		assert.Contains(t, blockIDs(changed), b.ID)
+
+		assert.Equal(t, []uuid.UUID{a1.ID, a2.ID}, blockIDs(tree.Children(a.ID)))
+		assert.Empty(t, tree.Children(b.ID))
+		updatedB, _ := tree.Get(b.ID)
+		assert.Empty(t, updatedB.ChildrenRecursive)
+
+		detached, _ := tree.Get(added.ID)
+		assert.Nil(t, detached.ParentID)
+		assert.Equal(t, []uuid.UUID{nested.ID}, detached.Content)
+		detachedChild, _ := tree.Get(nested.ID)
+		assert.Equal(t, added.ID, *detachedChild.ParentID)
+		assert.Equal(t, added.ID, *detachedChild.RootParentID)
+		assert.Equal(t, LifecycleStatusArchived, detachedChild.LifecycleStatus)
+
+		report, err = CheckIntegrity(tree.Blocks())
+		assert.NoError(t, err)
+		assert.False(t, report.HasIssues(), "%v", report.Issues)
+	})
+
+	t.Run("revert subtree detaches children gained by a block that moved out", func(t *testing.T) {
+		store := NewInMemoryRevisionStore()
+		page, a, a1, a2, b := newTestTreeBlocks()
+		for _, block := range []*Block{&page, &a, &a1, &a2, &b} {
+			setTitle(block, "v1", start)
+			_, err := RecordRevision(ctx, store, block)
+			assert.NoError(t, err)
+		}
+
+		tree, err := NewBlockTree([]Block{page, a, a1, a2, b})
+		assert.NoError(t, err)
+
+		// Later: a2 moves to b and gets a child there
+		_, err = MoveSubtree(tree, a2.ID, DestinationTypeBlock, b.ID, MoveOptions{})
+		assert.NoError(t, err)
+
+		movedA2, _ := tree.Get(a2.ID)
+		gained := movedA2.CreateChild()
+		movedA2.Content = []uuid.UUID{gained.ID}
+		movedA2.ChildrenRecursive = []uuid.UUID{gained.ID}
+		updatedB, _ := tree.Get(b.ID)
+		updatedB.ChildrenRecursive = append(updatedB.ChildrenRecursive, gained.ID)
+		updatedPage, _ := tree.Get(page.ID)
+		updatedPage.ChildrenRecursive = append(updatedPage.ChildrenRecursive, gained.ID)
+		tree.putAll([]Block{movedA2, gained, updatedB, updatedPage})
+
+		_, err = RevertSubtree(ctx, store, tree, a.ID, start.Add(30*time.Minute))
+		assert.NoError(t, err)
+
+		assert.Equal(t, []uuid.UUID{a1.ID, a2.ID}, blockIDs(tree.Children(a.ID)))
+		assert.Empty(t, tree.Children(a2.ID))
+		updatedB, _ = tree.Get(b.ID)
+		assert.Empty(t, updatedB.ChildrenRecursive)
+
+		detached, _ := tree.Get(gained.ID)
+		assert.Nil(t, detached.ParentID)
+		assert.Nil(t, detached.RootParentID)
+		assert.Equal(t, LifecycleStatusArchived, detached.LifecycleStatus)
+
+		report, err := CheckIntegrity(tree.Blocks())
+		assert.NoError(t, err)
+		assert.False(t, report.HasIssues(), "%v", report.Issues)
+	})
+}
@@ -0,0 +1,205 @@
+package blocks
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RoutingAnalyticsOptions configures AnalyzeRouting
+type RoutingAnalyticsOptions struct {
+	// CorrectionWindow is the longest time after a router move in which a manual move counts as a correction.
+	// Zero counts any following manual move.
+	CorrectionWindow time.Duration
+	// DriftCutoff splits the moves to a space into earlier and recent ones to compute the keyword drift.
+	// Zero uses the midpoint between the first and the last router move to the space.
+	DriftCutoff time.Time
+}
+
+// RoutingStats counts router moves and how many of them were corrected manually
+type RoutingStats struct {
+	Routed    int `json:"routed"`
+	Corrected int `json:"corrected"`
+}
+
+// Accuracy returns the share of router moves that were not corrected, or 0 if there were none
+func (s RoutingStats) Accuracy() float64 {
+	if s.Routed == 0 {
+		return 0
+	}
+	return float64(s.Routed-s.Corrected) / float64(s.Routed)
+}
+
+// KeywordDrift compares the space keywords used by the router for a space before and after a cutoff
+type KeywordDrift struct {
+	SpaceID uuid.UUID `json:"space_id"`
+	Cutoff  time.Time `json:"cutoff"`
+	// Before and After are the distinct keywords of the moves before and after the cutoff, sorted
+	Before []string `json:"before"`
+	After  []string `json:"after"`
+	// Added and Removed are the keywords only found after, respectively before, the cutoff, sorted
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	// Similarity is the Jaccard similarity of Before and After, 1 if both are empty
+	Similarity float64 `json:"similarity"`
+}
+
+// RoutingReport describes how well the router performed on a set of blocks
+type RoutingReport struct {
+	RoutingStats
+	// Spaces has the statistics per space the router moved blocks to
+	Spaces map[uuid.UUID]RoutingStats `json:"spaces"`
+	// Confusion counts, per space chosen by the router, the space the block ended up in after a manual
+	// correction. Uncorrected moves count towards the chosen space itself.
+	Confusion map[uuid.UUID]map[uuid.UUID]int `json:"confusion"`
+	// Drift has the keyword drift of every space the router moved blocks to, sorted by space ID
+	Drift []KeywordDrift `json:"drift"`
+}
+
+// AnalyzeRouting evaluates the router moves found in the MovesHistory of the blocks.
+//
+// A router move is corrected by the first following manual move, before the next router move of the block, that
+// takes the block to a different space than the router chose. With a CorrectionWindow, only manual moves made
+// within the window after the router move count. Manual moves within the chosen space, such as reordering or
+// moving the block into a note of that space, are not corrections.
+//
+// Spaces are taken from the FromSpaceID and ToSpaceID recorded with every move, so the report reflects the
+// spaces at the time of the moves. Router moves whose destination space is unknown are ignored.
+func AnalyzeRouting(blocks []Block, options RoutingAnalyticsOptions) RoutingReport {
+	report := RoutingReport{
+		Spaces:    make(map[uuid.UUID]RoutingStats),
+		Confusion: make(map[uuid.UUID]map[uuid.UUID]int),
+		Drift:     []KeywordDrift{},
+	}
+
+	routedMoves := make(map[uuid.UUID][]Move)
+	for _, b := range blocks {
+		for i, move := range b.MovesHistory {
+			if move.Reason != MoveReasonRouter {
+				continue
+			}
+
+			predicted, ok := move.ToSpace()
+			if !ok {
+				continue
+			}
+			routedMoves[predicted] = append(routedMoves[predicted], move)
+
+			stats := report.Spaces[predicted]
+			stats.Routed++
+			report.Routed++
+
+			actual, corrected := correctedSpace(move, predicted, b.MovesHistory[i+1:], options.CorrectionWindow)
+			if corrected {
+				stats.Corrected++
+				report.Corrected++
+			} else {
+				actual = predicted
+			}
+			report.Spaces[predicted] = stats
+
+			if report.Confusion[predicted] == nil {
+				report.Confusion[predicted] = make(map[uuid.UUID]int)
+			}
+			report.Confusion[predicted][actual]++
+		}
+	}
+
+	spaceIDs := make([]uuid.UUID, 0, len(routedMoves))
+	for spaceID := range routedMoves {
+		spaceIDs = append(spaceIDs, spaceID)
+	}
+	sortIDs(spaceIDs)
+	for _, spaceID := range spaceIDs {
+		report.Drift = append(report.Drift, keywordDrift(spaceID, routedMoves[spaceID], options.DriftCutoff))
+	}
+
+	return report
+}
+
+// correctedSpace returns the space of the first manual move out of the predicted space within the window,
+// looking at the moves following the router move up to the next router move
+func correctedSpace(move Move, predicted uuid.UUID, following []Move, window time.Duration) (uuid.UUID, bool) {
+	for _, next := range following {
+		if next.Reason == MoveReasonRouter {
+			break
+		}
+		if window > 0 && next.Timestamp.Sub(move.Timestamp) > window {
+			break
+		}
+		if next.Reason != MoveReasonManual {
+			continue
+		}
+		if space, ok := next.ToSpace(); ok && space != predicted {
+			return space, true
+		}
+	}
+	return uuid.Nil, false
+}
+
+// keywordDrift compares the space keywords of the moves before and after the cutoff
+func keywordDrift(spaceID uuid.UUID, moves []Move, cutoff time.Time) KeywordDrift {
+	if cutoff.IsZero() {
+		first, last := moves[0].Timestamp, moves[0].Timestamp
+		for _, move := range moves[1:] {
+			if move.Timestamp.Before(first) {
+				first = move.Timestamp
+			}
+			if move.Timestamp.After(last) {
+				last = move.Timestamp
+			}
+		}
+		cutoff = first.Add(last.Sub(first) / 2)
+	}
+
+	before, after := make(map[string]bool), make(map[string]bool)
+	for _, move := range moves {
+		keywords := after
+		if move.Timestamp.Before(cutoff) {
+			keywords = before
+		}
+		for _, keyword := range move.SpaceKeywords {
+			keywords[keyword] = true
+		}
+	}
+
+	drift := KeywordDrift{
+		SpaceID: spaceID,
+		Cutoff:  cutoff,
+		Before:  sortedKeys(before),
+		After:   sortedKeys(after),
+		Added:   []string{},
+		Removed: []string{},
+	}
+
+	var shared int
+	for _, keyword := range drift.After {
+		if before[keyword] {
+			shared++
+		} else {
+			drift.Added = append(drift.Added, keyword)
+		}
+	}
+	for _, keyword := range drift.Before {
+		if !after[keyword] {
+			drift.Removed = append(drift.Removed, keyword)
+		}
+	}
+
+	drift.Similarity = 1
+	if union := len(before) + len(after) - shared; union > 0 {
+		drift.Similarity = float64(shared) / float64(union)
+	}
+	return drift
+}
+
+// sortedKeys returns the keys of the set in ascending order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
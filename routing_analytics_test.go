@@ -0,0 +1,101 @@
+package blocks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyzeRouting(t *testing.T) {
+	recipes, work, inbox := uuid.New(), uuid.New(), uuid.New()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(hours int) time.Time { return start.Add(time.Duration(hours) * time.Hour) }
+
+	routed := func(space uuid.UUID, hours int, keywords ...string) Move {
+		return Move{FromType: DestinationTypeSpace, FromID: inbox, ToType: DestinationTypeSpace, ToID: space,
+			Timestamp: at(hours), Reason: MoveReasonRouter, SpaceKeywords: keywords}
+	}
+	manual := func(toType DestinationType, to uuid.UUID, space uuid.UUID, hours int) Move {
+		return Move{ToType: toType, ToID: to, ToSpaceID: &space, Timestamp: at(hours), Reason: MoveReasonManual}
+	}
+
+	// The note was in the work space when the block was moved into it and has moved since
+	note := NewEmptyBlock()
+	note.SpaceID = recipes
+
+	correct := NewEmptyBlock()
+	correct.MovesHistory = []Move{routed(recipes, 0, "cooking", "food")}
+
+	corrected := NewEmptyBlock()
+	corrected.MovesHistory = []Move{routed(recipes, 1, "food"), manual(DestinationTypeSpace, work, work, 2)}
+
+	correctedToNote := NewEmptyBlock()
+	correctedToNote.MovesHistory = []Move{routed(recipes, 10, "food", "baking"), manual(DestinationTypeNote, note.ID, work, 40)}
+
+	reordered := NewEmptyBlock()
+	reordered.MovesHistory = []Move{routed(work, 3, "meeting"), manual(DestinationTypeBlock, uuid.New(), work, 4)}
+
+	rerouted := NewEmptyBlock()
+	rerouted.MovesHistory = []Move{routed(work, 5), routed(recipes, 6), manual(DestinationTypeSpace, work, work, 7)}
+
+	blocks := []Block{note, correct, corrected, correctedToNote, reordered, rerouted}
+
+	t.Run("accuracy and confusion", func(t *testing.T) {
+		report := AnalyzeRouting(blocks, RoutingAnalyticsOptions{})
+
+		assert.Equal(t, RoutingStats{Routed: 6, Corrected: 3}, report.RoutingStats)
+		assert.Equal(t, 0.5, report.Accuracy())
+		assert.Equal(t, RoutingStats{Routed: 4, Corrected: 3}, report.Spaces[recipes])
+		assert.Equal(t, 0.25, report.Spaces[recipes].Accuracy())
+		assert.Equal(t, 1.0, report.Spaces[work].Accuracy())
+
+		assert.Equal(t, map[uuid.UUID]map[uuid.UUID]int{
+			recipes: {recipes: 1, work: 3},
+			work:    {work: 2},
+		}, report.Confusion)
+	})
+
+	t.Run("correction window", func(t *testing.T) {
+		report := AnalyzeRouting(blocks, RoutingAnalyticsOptions{CorrectionWindow: 2 * time.Hour})
+
+		assert.Equal(t, RoutingStats{Routed: 6, Corrected: 2}, report.RoutingStats)
+		assert.Equal(t, map[uuid.UUID]int{recipes: 2, work: 2}, report.Confusion[recipes])
+	})
+
+	t.Run("keyword drift", func(t *testing.T) {
+		report := AnalyzeRouting(blocks, RoutingAnalyticsOptions{DriftCutoff: at(5)})
+
+		var drift KeywordDrift
+		for _, d := range report.Drift {
+			if d.SpaceID == recipes {
+				drift = d
+			}
+		}
+		assert.Equal(t, []string{"cooking", "food"}, drift.Before)
+		assert.Equal(t, []string{"baking", "food"}, drift.After)
+		assert.Equal(t, []string{"baking"}, drift.Added)
+		assert.Equal(t, []string{"cooking"}, drift.Removed)
+		assert.InDelta(t, 1.0/3, drift.Similarity, 1e-9)
+
+		report = AnalyzeRouting(blocks, RoutingAnalyticsOptions{})
+		for _, d := range report.Drift {
+			if d.SpaceID == recipes {
+				assert.Equal(t, at(5), d.Cutoff)
+			}
+			if d.SpaceID == work {
+				assert.Equal(t, []string{"meeting"}, d.Removed)
+				assert.Empty(t, d.After)
+				assert.Equal(t, 0.0, d.Similarity)
+			}
+		}
+	})
+
+	t.Run("no router moves", func(t *testing.T) {
+		report := AnalyzeRouting([]Block{note}, RoutingAnalyticsOptions{})
+		assert.Equal(t, 0.0, report.Accuracy())
+		assert.Empty(t, report.Spaces)
+		assert.Empty(t, report.Drift)
+	})
+}
@@ -419,3 +419,14 @@ func GetSeriesProperties() []string {
 		PropertyKeyChecked,
 	}
 }
+
+func init() {
+	mustRegisterType(TypeDefinition{
+		DataType:     TypeSeries,
+		RenderFunc:   renderWith(RenderSeriesProperties),
+		PropertyKeys: GetSeriesProperties(),
+		Content:      BlockContentTypeStructural,
+		Final:        true,
+		Schema:       NewPropertySchema(GetSeriesProperties()...).Require(PropertyKeyTitle),
+	})
+}
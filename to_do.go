@@ -123,3 +123,13 @@ func GetToDoProperties() []string {
 		PropertyKeyReminderOffset,
 	}
 }
+
+func init() {
+	mustRegisterType(TypeDefinition{
+		DataType:     TypeToDo,
+		RenderFunc:   renderWith(RenderToDoProperties),
+		PropertyKeys: GetToDoProperties(),
+		Content:      BlockContentTypeStructural,
+		Schema:       NewPropertySchema(GetToDoProperties()...).Require(PropertyKeyTitle, PropertyKeyChecked),
+	})
+}
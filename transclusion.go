@@ -0,0 +1,142 @@
+package blocks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// DefaultTransclusionDepth is the depth limit used when TransclusionOptions.MaxDepth is not set
+const DefaultTransclusionDepth = 3
+
+// TransclusionMode defines what replaces a [[block:uuid]] annotation
+type TransclusionMode int
+
+const (
+	// TransclusionContent inlines the rendered content of the referenced block and its children
+	TransclusionContent TransclusionMode = iota
+	// TransclusionTitle inlines only the title of the referenced block
+	TransclusionTitle
+)
+
+// TransclusionOptions configures RenderContentWithTransclusion
+type TransclusionOptions struct {
+	Mode TransclusionMode
+
+	// MaxDepth limits how many references are followed inside each other; 0 uses DefaultTransclusionDepth.
+	// References beyond the limit are replaced by the title of the referenced block.
+	MaxDepth int
+}
+
+// RenderContentWithTransclusion renders the block like RenderContent, but resolves [[block:uuid]] annotations
+// through the lookup map and replaces them with the referenced material.
+// References to unknown blocks, or to blocks without a title when only the title can be used, are kept as is.
+// A block that references itself or one of the blocks it is being rendered inside is replaced by its title.
+// Rendering stops with the context error if the context is done.
+func RenderContentWithTransclusion(ctx context.Context, b Block, lookupBlocks map[uuid.UUID]Block, options TransclusionOptions) (string, error) {
+	if options.MaxDepth <= 0 {
+		options.MaxDepth = DefaultTransclusionDepth
+	}
+
+	t := &transcluder{
+		ctx:          ctx,
+		lookupBlocks: lookupBlocks,
+		options:      options,
+		rendering:    make(map[uuid.UUID]bool),
+	}
+	return t.render(b, 0, make(map[uuid.UUID]bool))
+}
+
+// transcluder holds the state of a single RenderContentWithTransclusion call
+type transcluder struct {
+	ctx          context.Context
+	lookupBlocks map[uuid.UUID]Block
+	options      TransclusionOptions
+
+	// rendering contains the blocks currently being rendered, to detect reference cycles
+	rendering map[uuid.UUID]bool
+}
+
+// render renders the block and its children, following the same rules as renderContentWithCycleDetection
+func (t *transcluder) render(b Block, depth int, visitedBlocks map[uuid.UUID]bool) (string, error) {
+	if err := t.ctx.Err(); err != nil {
+		return "", err
+	}
+	if visitedBlocks[b.ID] {
+		return "", nil
+	}
+	visitedBlocks[b.ID] = true
+
+	t.rendering[b.ID] = true
+	defer delete(t.rendering, b.ID)
+
+	var renderedContent []string
+	ownContent, err := t.resolve(RenderProperties(t.ctx, b), depth)
+	if err != nil {
+		return "", err
+	}
+	if ownContent != "" {
+		renderedContent = append(renderedContent, ownContent)
+	}
+
+	for _, blockID := range b.Content {
+		childBlock, exists := t.lookupBlocks[blockID]
+		if !exists {
+			continue
+		}
+		childContent, err := t.render(childBlock, depth, visitedBlocks)
+		if err != nil {
+			return "", err
+		}
+		if childContent != "" {
+			renderedContent = append(renderedContent, childContent)
+		}
+	}
+
+	return strings.Join(renderedContent, "\n"), nil
+}
+
+// resolve replaces the annotations in the text with the referenced material.
+// It returns the first error met while rendering a referenced block.
+func (t *transcluder) resolve(text string, depth int) (string, error) {
+	if !strings.Contains(text, "[[block:") {
+		return text, nil
+	}
+
+	var renderErr error
+	resolved := referencePattern.ReplaceAllStringFunc(text, func(annotation string) string {
+		if renderErr != nil {
+			return annotation
+		}
+		id, err := uuid.Parse(referencePattern.FindStringSubmatch(annotation)[1])
+		if err != nil {
+			return annotation
+		}
+		referenced, exists := t.lookupBlocks[id]
+		if !exists {
+			return annotation
+		}
+
+		if t.options.Mode == TransclusionContent && depth < t.options.MaxDepth && !t.rendering[id] {
+			content, err := t.render(referenced, depth+1, make(map[uuid.UUID]bool))
+			if err != nil {
+				renderErr = fmt.Errorf("failed to render referenced block %s: %w", id, err)
+				return annotation
+			}
+			if content != "" {
+				return content
+			}
+		}
+
+		if title, ok := referenced.Properties.GetString(PropertyKeyTitle); ok && title != "" {
+			return title
+		}
+		return annotation
+	})
+	if renderErr != nil {
+		return "", renderErr
+	}
+	return resolved, nil
+}
@@ -0,0 +1,69 @@
+package blocks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderContentWithTransclusion(t *testing.T) {
+	ctx := context.Background()
+
+	newParagraph := func(title string) Block {
+		b := NewEmptyBlock()
+		b.Type = TypeParagraph
+		b.Properties[PropertyKeyTitle] = []interface{}{title}
+		return b
+	}
+
+	recipe := newParagraph("Pancakes")
+	step := newParagraph("Mix flour and eggs")
+	recipe.Content = []uuid.UUID{step.ID}
+
+	missing := "[[block:" + uuid.New().String() + "]]"
+	note := newParagraph("Try " + recipe.AnnotationID() + " " + missing)
+
+	lookup := map[uuid.UUID]Block{recipe.ID: recipe, step.ID: step, note.ID: note}
+
+	t.Run("inline content", func(t *testing.T) {
+		rendered, err := RenderContentWithTransclusion(ctx, note, lookup, TransclusionOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, "Try Pancakes\nMix flour and eggs "+missing, rendered)
+	})
+
+	t.Run("title only", func(t *testing.T) {
+		rendered, err := RenderContentWithTransclusion(ctx, note, lookup, TransclusionOptions{Mode: TransclusionTitle})
+		assert.NoError(t, err)
+		assert.Equal(t, "Try Pancakes "+missing, rendered)
+	})
+
+	t.Run("depth limit", func(t *testing.T) {
+		outer := newParagraph("See " + note.AnnotationID())
+		lookup := map[uuid.UUID]Block{recipe.ID: recipe, step.ID: step, note.ID: note, outer.ID: outer}
+
+		rendered, err := RenderContentWithTransclusion(ctx, outer, lookup, TransclusionOptions{MaxDepth: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, "See Try Pancakes "+missing, rendered)
+	})
+
+	t.Run("reference cycles", func(t *testing.T) {
+		a, b := newParagraph("A"), newParagraph("B")
+		a.Properties[PropertyKeyTitle] = []interface{}{"A then " + b.AnnotationID()}
+		b.Properties[PropertyKeyTitle] = []interface{}{"B then " + a.AnnotationID()}
+		lookup := map[uuid.UUID]Block{a.ID: a, b.ID: b}
+
+		rendered, err := RenderContentWithTransclusion(ctx, a, lookup, TransclusionOptions{MaxDepth: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, "A then B then A then "+b.AnnotationID(), rendered)
+	})
+
+	t.Run("context errors are returned", func(t *testing.T) {
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err := RenderContentWithTransclusion(canceled, note, lookup, TransclusionOptions{})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -554,3 +554,13 @@ func AddTweetPropertiesFromJSON(block *Block, rawJSON json.RawMessage) error {
 
 	return AddTweetPropertiesFromDataTweet(block, tweetData)
 }
+
+func init() {
+	mustRegisterType(TypeDefinition{
+		DataType:     TypeTweet,
+		RenderFunc:   renderWith(RenderTweetProperties),
+		PropertyKeys: GetTweetProperties(),
+		Content:      BlockContentTypeStructural,
+		Schema:       NewPropertySchema(GetTweetProperties()...).Require(PropertyKeyTweetID),
+	})
+}
@@ -38,42 +38,14 @@ const (
 	TypeHeader6          DataType = "heading_6"
 	TypeBulletListItem   DataType = "bullet_list_item"
 	TypeNumberedListItem DataType = "numbered_list_item"
+	TypeCode             DataType = "code"
+	TypeQuote            DataType = "quote"
 )
 
-// IsValid checks if the DataType is one of the defined valid types
+// IsValid checks if the DataType has a registered TypeHandler
 func (d DataType) IsValid() bool {
-	switch d {
-	case TypeFragment,
-		TypePage,
-		TypeImage,
-		TypeVideo,
-		TypeAudio,
-		TypeFile,
-		TypeDatabase,
-		TypeLink,
-		TypeToDo,
-		TypeEmail,
-		TypeMovie,
-		TypeInstagram,
-		TypeYouTube,
-		TypeTweet,
-		TypeSeries,
-		TypeBook,
-		TypeLine,
-		TypePerson,
-		TypePlace,
-		TypeParagraph,
-		TypeHeader1,
-		TypeHeader2,
-		TypeHeader3,
-		TypeHeader4,
-		TypeHeader5,
-		TypeHeader6,
-		TypeBulletListItem,
-		TypeNumberedListItem:
-		return true
-	}
-	return false
+	_, ok := LookupType(d)
+	return ok
 }
 
 type BlockContentType string
@@ -85,19 +57,19 @@ func (b BlockContentType) String() string {
 const BlockContentTypeTextual BlockContentType = "textual"
 const BlockContentTypeStructural BlockContentType = "structural"
 
+// ContentType returns the content type declared by the registered TypeHandler.
+// Unknown types are considered structural.
 func (d DataType) ContentType() BlockContentType {
-	switch d {
-	case TypePage, TypeParagraph, TypeHeader1, TypeHeader2, TypeHeader3, TypeHeader4, TypeHeader5, TypeHeader6, TypeBulletListItem, TypeNumberedListItem, TypeLine:
-		return BlockContentTypeTextual
+	if h, ok := LookupType(d); ok {
+		return h.ContentType()
 	}
 	return BlockContentTypeStructural
 }
 
+// IsFinal reports whether the registered TypeHandler declares the type as final
 func (d DataType) IsFinal() bool {
-	switch d {
-	case TypeMovie, TypeSeries, TypeBook, TypePlace, TypePerson, TypeImage, TypeVideo, TypeAudio, TypeFile:
-		return true
-	default:
-		return false
+	if h, ok := LookupType(d); ok {
+		return h.IsFinal()
 	}
+	return false
 }
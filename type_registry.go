@@ -0,0 +1,221 @@
+package blocks
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// TypeHandler describes everything the package needs to know about a block type:
+// how to render it, which properties it supports, whether it is textual or structural,
+// whether it is a final type and how to validate it.
+// Custom types can be plugged in from outside the package with RegisterType.
+type TypeHandler interface {
+	// Type returns the DataType handled by this handler
+	Type() DataType
+
+	// Render renders the block properties in a human-readable (markdown) format
+	Render(ctx context.Context, b Block) string
+
+	// Properties returns the list of property keys supported by the type
+	Properties() []string
+
+	// ContentType returns whether the type is textual or structural
+	ContentType() BlockContentType
+
+	// IsFinal reports whether the type is a final entity type
+	IsFinal() bool
+
+	// Validate checks the block and returns an error if it is not a valid block of this type
+	Validate(b Block) error
+}
+
+// TypeDefinition is a TypeHandler built from plain values and functions.
+// It is used for all built-in types and is the easiest way to register a custom type.
+type TypeDefinition struct {
+	DataType     DataType
+	RenderFunc   func(ctx context.Context, b Block) string
+	PropertyKeys []string
+	Content      BlockContentType
+	Final        bool
+	ValidateFunc func(b Block) error
+
+	// Schema declares the properties of the type.
+	// When nil, a schema allowing exactly PropertyKeys is derived.
+	Schema *PropertySchema
+}
+
+func (d TypeDefinition) Type() DataType {
+	return d.DataType
+}
+
+func (d TypeDefinition) Render(ctx context.Context, b Block) string {
+	if d.RenderFunc == nil {
+		return ""
+	}
+	return d.RenderFunc(ctx, b)
+}
+
+func (d TypeDefinition) Properties() []string {
+	return append([]string{}, d.PropertyKeys...)
+}
+
+func (d TypeDefinition) ContentType() BlockContentType {
+	if d.Content == "" {
+		return BlockContentTypeStructural
+	}
+	return d.Content
+}
+
+func (d TypeDefinition) IsFinal() bool {
+	return d.Final
+}
+
+// PropertySchema returns the declared schema or the one derived from PropertyKeys
+func (d TypeDefinition) PropertySchema() *PropertySchema {
+	if d.Schema != nil {
+		return d.Schema
+	}
+	return NewPropertySchema(d.PropertyKeys...)
+}
+
+// Validate runs the type-specific validation. Use the package level Validate
+// to validate a whole block including its property schema.
+func (d TypeDefinition) Validate(b Block) error {
+	if d.ValidateFunc == nil {
+		return nil
+	}
+	return d.ValidateFunc(b)
+}
+
+// renderWith adapts a Render[Type]Properties function to the TypeDefinition.RenderFunc signature
+func renderWith(render func(b Block) string) func(ctx context.Context, b Block) string {
+	return func(_ context.Context, b Block) string {
+		return render(b)
+	}
+}
+
+// typeRegistry keeps the registered type handlers, keyed by DataType
+type typeRegistry struct {
+	mu       sync.RWMutex
+	handlers map[DataType]TypeHandler
+}
+
+var defaultTypeRegistry = &typeRegistry{handlers: make(map[DataType]TypeHandler)}
+
+func (r *typeRegistry) register(h TypeHandler) error {
+	if h == nil {
+		return ErrInvalidTypeHandler
+	}
+	if h.Type() == "" {
+		return fmt.Errorf("%w: empty data type", ErrInvalidTypeHandler)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.handlers[h.Type()]; exists {
+		return fmt.Errorf("%w: %s", ErrTypeAlreadyRegistered, h.Type())
+	}
+	r.handlers[h.Type()] = h
+	return nil
+}
+
+func (r *typeRegistry) unregister(t DataType) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, exists := r.handlers[t]
+	delete(r.handlers, t)
+	return exists
+}
+
+func (r *typeRegistry) lookup(t DataType) (TypeHandler, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	h, ok := r.handlers[t]
+	return h, ok
+}
+
+func (r *typeRegistry) types() []DataType {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	types := make([]DataType, 0, len(r.handlers))
+	for t := range r.handlers {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// RegisterType registers a handler for a block type.
+// It returns ErrTypeAlreadyRegistered if a handler for the same type already exists.
+func RegisterType(h TypeHandler) error {
+	return defaultTypeRegistry.register(h)
+}
+
+// UnregisterType removes the handler of the given type and reports whether it existed
+func UnregisterType(t DataType) bool {
+	return defaultTypeRegistry.unregister(t)
+}
+
+// LookupType returns the handler registered for the given type
+func LookupType(t DataType) (TypeHandler, bool) {
+	return defaultTypeRegistry.lookup(t)
+}
+
+// RegisteredTypes returns all registered types sorted alphabetically
+func RegisteredTypes() []DataType {
+	return defaultTypeRegistry.types()
+}
+
+// mustRegisterType registers a built-in type and panics on failure,
+// which can only happen if two built-in files register the same type
+func mustRegisterType(h TypeHandler) {
+	if err := RegisterType(h); err != nil {
+		panic(err)
+	}
+}
+
+func init() {
+	// Types without a dedicated file
+	mustRegisterType(TypeDefinition{
+		DataType: TypeFragment,
+		Content:  BlockContentTypeStructural,
+		Schema:   &PropertySchema{AllowUnknown: true},
+	})
+	mustRegisterType(TypeDefinition{
+		DataType:     TypePage,
+		RenderFunc:   renderWith(RenderPageProperties),
+		PropertyKeys: GetPageProperties(),
+		Content:      BlockContentTypeTextual,
+	})
+	mustRegisterType(TypeDefinition{
+		DataType: TypeDatabase,
+		Content:  BlockContentTypeStructural,
+		Schema:   &PropertySchema{AllowUnknown: true},
+	})
+}
+
+// RenderPageProperties renders the title of a page block
+func RenderPageProperties(b Block) string {
+	title, ok := b.Properties.Get(PropertyKeyTitle)
+	if !ok {
+		return ""
+	}
+	titleString, ok := title.(string)
+	if !ok {
+		return ""
+	}
+	return titleString
+}
+
+// GetPageProperties returns a list of all page property keys
+func GetPageProperties() []string {
+	return []string{
+		PropertyKeyTitle,
+	}
+}
@@ -0,0 +1,87 @@
+package blocks
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypeRegistry(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("built-in types are registered", func(t *testing.T) {
+		for _, dataType := range []DataType{TypeFragment, TypePage, TypeDatabase, TypeParagraph, TypeTweet, TypeHeader3, TypePlace} {
+			assert.True(t, dataType.IsValid(), "expected %s to be registered", dataType)
+		}
+		assert.False(t, DataType("unknown").IsValid())
+	})
+
+	t.Run("content type and finality come from the handler", func(t *testing.T) {
+		assert.Equal(t, BlockContentTypeTextual, TypeParagraph.ContentType())
+		assert.Equal(t, BlockContentTypeTextual, TypeLine.ContentType())
+		assert.Equal(t, BlockContentTypeStructural, TypeMovie.ContentType())
+		assert.Equal(t, BlockContentTypeStructural, DataType("unknown").ContentType())
+		assert.True(t, TypeMovie.IsFinal())
+		assert.False(t, TypeLink.IsFinal())
+	})
+
+	t.Run("tweets are rendered", func(t *testing.T) {
+		block := NewEmptyBlock()
+		block.Type = TypeTweet
+		block.Properties[PropertyKeyTitle] = []interface{}{"Hello world"}
+		block.Properties[PropertyKeyUsername] = []interface{}{"gopher"}
+
+		assert.Equal(t, RenderTweetProperties(block), RenderProperties(ctx, block))
+		assert.NotEmpty(t, RenderProperties(ctx, block))
+	})
+
+	t.Run("custom type can be registered", func(t *testing.T) {
+		custom := DataType("recipe")
+		errMissingTitle := errors.New("missing title")
+		err := RegisterType(TypeDefinition{
+			DataType: custom,
+			RenderFunc: func(_ context.Context, b Block) string {
+				title, _ := b.Properties.GetString(PropertyKeyTitle)
+				return "Recipe: " + title
+			},
+			PropertyKeys: []string{PropertyKeyTitle},
+			Content:      BlockContentTypeStructural,
+			Final:        true,
+			ValidateFunc: func(b Block) error {
+				if !b.Properties.Has(PropertyKeyTitle) {
+					return errMissingTitle
+				}
+				return nil
+			},
+		})
+		assert.NoError(t, err)
+		defer UnregisterType(custom)
+
+		block := NewEmptyBlock()
+		block.Type = custom
+		block.Properties[PropertyKeyTitle] = []interface{}{"Pancakes"}
+
+		assert.True(t, custom.IsValid())
+		assert.True(t, custom.IsFinal())
+		assert.Equal(t, "Recipe: Pancakes", RenderProperties(ctx, block))
+		assert.Contains(t, RegisteredTypes(), custom)
+
+		handler, ok := LookupType(custom)
+		assert.True(t, ok)
+		assert.Equal(t, []string{PropertyKeyTitle}, handler.Properties())
+		assert.NoError(t, handler.Validate(block))
+		assert.ErrorIs(t, handler.Validate(NewEmptyBlock()), errMissingTitle)
+	})
+
+	t.Run("duplicate registration fails", func(t *testing.T) {
+		err := RegisterType(TypeDefinition{DataType: TypeParagraph})
+		assert.ErrorIs(t, err, ErrTypeAlreadyRegistered)
+	})
+
+	t.Run("invalid handler fails", func(t *testing.T) {
+		assert.ErrorIs(t, RegisterType(nil), ErrInvalidTypeHandler)
+		assert.ErrorIs(t, RegisterType(TypeDefinition{}), ErrInvalidTypeHandler)
+	})
+}
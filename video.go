@@ -125,3 +125,13 @@ func GetVideoProperties() []string {
 		PropertyKeyEnriched,
 	}
 }
+
+func init() {
+	mustRegisterType(TypeDefinition{
+		DataType:     TypeVideo,
+		RenderFunc:   renderWith(RenderVideoProperties),
+		PropertyKeys: GetVideoProperties(),
+		Content:      BlockContentTypeStructural,
+		Final:        true,
+	})
+}
@@ -0,0 +1,159 @@
+package blocks
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldAccess defines who may change a block field
+type FieldAccess string
+
+func (a FieldAccess) String() string {
+	return string(a)
+}
+
+const (
+	// FieldAccessUserEditable fields can be changed by users and by the system
+	FieldAccessUserEditable FieldAccess = "user_editable"
+	// FieldAccessSystemOnly fields are maintained by the system, e.g. hierarchy fields or enrichment results
+	FieldAccessSystemOnly FieldAccess = "system_only"
+	// FieldAccessImmutable fields never change after the block is created
+	FieldAccessImmutable FieldAccess = "immutable"
+)
+
+// WriteRole identifies the kind of caller updating a block
+type WriteRole string
+
+const (
+	WriteRoleUser   WriteRole = "user"
+	WriteRoleSystem WriteRole = "system"
+)
+
+// CanWrite reports whether the role may change a field with the given access
+func (r WriteRole) CanWrite(access FieldAccess) bool {
+	switch access {
+	case FieldAccessUserEditable:
+		return r == WriteRoleUser || r == WriteRoleSystem
+	case FieldAccessSystemOnly:
+		return r == WriteRoleSystem
+	default:
+		return false
+	}
+}
+
+// WritePolicy maps JSON field names of Block to their access.
+// Fields missing from the policy are treated as system-only.
+type WritePolicy map[string]FieldAccess
+
+// DefaultWritePolicy returns the write policy for Block fields
+func DefaultWritePolicy() WritePolicy {
+	return WritePolicy{
+		BlockPropertyID:                FieldAccessImmutable,
+		BlockPropertyAccountID:         FieldAccessImmutable,
+		BlockPropertyCreatorUserID:     FieldAccessImmutable,
+		BlockPropertyOriginalSpaceID:   FieldAccessImmutable,
+		BlockPropertyCreatedAt:         FieldAccessImmutable,
+		BlockPropertyType:              FieldAccessSystemOnly,
+		BlockPropertyRootParentID:      FieldAccessSystemOnly,
+		BlockPropertyParentID:          FieldAccessSystemOnly,
+		BlockPropertySpaceID:           FieldAccessSystemOnly,
+		BlockPropertyPreviousSpaceID:   FieldAccessSystemOnly,
+		BlockPropertyChildrenRecursive: FieldAccessSystemOnly,
+		BlockPropertyLifecycleStatus:   FieldAccessSystemOnly,
+		BlockPropertyStatusHistory:     FieldAccessSystemOnly,
+		BlockPropertyOrigin:            FieldAccessSystemOnly,
+		BlockPropertyMovesHistory:      FieldAccessSystemOnly,
+		BlockPropertyLastError:         FieldAccessSystemOnly,
+		BlockPropertyUpdatedAt:         FieldAccessSystemOnly,
+		BlockPropertyVersion:           FieldAccessSystemOnly,
+		BlockPropertyProperties:        FieldAccessUserEditable,
+		BlockPropertyStypes:            FieldAccessUserEditable,
+		BlockPropertyContent:           FieldAccessUserEditable,
+		BlockPropertyRawBody:           FieldAccessUserEditable,
+		BlockPropertyMetadata:          FieldAccessUserEditable,
+		BlockPropertyLastViewedAt:      FieldAccessUserEditable,
+		BlockPropertyMeaning:           FieldAccessUserEditable,
+		BlockPropertyClassification:    FieldAccessUserEditable,
+	}
+}
+
+// Access returns the access of the field
+func (p WritePolicy) Access(field string) FieldAccess {
+	if access, exists := p[field]; exists {
+		return access
+	}
+	return FieldAccessSystemOnly
+}
+
+// Check returns a FieldWriteError listing the fields the role may not change
+func (p WritePolicy) Check(role WriteRole, fields []string) error {
+	var rejected []RejectedField
+	for _, field := range fields {
+		access := p.Access(field)
+		if !role.CanWrite(access) {
+			rejected = append(rejected, RejectedField{Field: field, Access: access})
+		}
+	}
+
+	if len(rejected) == 0 {
+		return nil
+	}
+	return FieldWriteError{Role: role, Fields: rejected}
+}
+
+// RejectedField is a field that a write policy did not allow to change
+type RejectedField struct {
+	Field  string      `json:"field"`
+	Access FieldAccess `json:"access"`
+}
+
+// FieldWriteError is returned when an update changes fields the caller may not write
+type FieldWriteError struct {
+	Role   WriteRole       `json:"role"`
+	Fields []RejectedField `json:"fields"`
+}
+
+func (e FieldWriteError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		fields = append(fields, fmt.Sprintf("%s (%s)", f.Field, f.Access))
+	}
+	return fmt.Sprintf("%s: role %s cannot write %s", ErrFieldNotWritable, e.Role, strings.Join(fields, ", "))
+}
+
+func (e FieldWriteError) Unwrap() error {
+	return ErrFieldNotWritable
+}
+
+// RejectedFieldNames returns the names of the rejected fields
+func (e FieldWriteError) RejectedFieldNames() []string {
+	names := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		names = append(names, f.Field)
+	}
+	return names
+}
+
+// UpdateFromJSONWithPolicy works like UpdateFromJSON, but first checks which fields the patch would change.
+// If the role may not write one of them, the block is left untouched and a FieldWriteError is returned.
+// Fields sent with their current value are not rejected, so clients can send whole blocks.
+func (b *Block) UpdateFromJSONWithPolicy(data []byte, role WriteRole, policy WritePolicy) ([]string, error) {
+	updated := b.Copy()
+	updatedFields, err := updated.UpdateFromJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	changedFields := make([]string, 0, len(updatedFields))
+	for _, field := range updatedFields {
+		if field != BlockPropertyUpdatedAt {
+			changedFields = append(changedFields, field)
+		}
+	}
+	if err := policy.Check(role, changedFields); err != nil {
+		return nil, err
+	}
+
+	*b = updated
+	return updatedFields, nil
+}
@@ -0,0 +1,89 @@
+package blocks
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateFromJSONWithPolicy(t *testing.T) {
+	policy := DefaultWritePolicy()
+
+	newBlock := func() *Block {
+		b := NewEmptyBlock()
+		b.AccountID = uuid.New()
+		b.RawBody = "Original body"
+		return &b
+	}
+
+	t.Run("user edits allowed fields", func(t *testing.T) {
+		b := newBlock()
+
+		updatedFields, err := b.UpdateFromJSONWithPolicy([]byte(`{
+			"id": "`+b.ID.String()+`",
+			"raw_body": "New body"
+		}`), WriteRoleUser, policy)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"raw_body", "updated_at"}, updatedFields)
+		assert.Equal(t, "New body", b.RawBody)
+	})
+
+	t.Run("rejected fields leave the block untouched", func(t *testing.T) {
+		b := newBlock()
+		original := b.Copy()
+
+		updatedFields, err := b.UpdateFromJSONWithPolicy([]byte(`{
+			"account_id": "`+uuid.New().String()+`",
+			"parent_id": "`+uuid.New().String()+`",
+			"raw_body": "New body"
+		}`), WriteRoleUser, policy)
+
+		assert.Nil(t, updatedFields)
+		assert.ErrorIs(t, err, ErrFieldNotWritable)
+
+		var writeErr FieldWriteError
+		assert.True(t, errors.As(err, &writeErr))
+		assert.Equal(t, WriteRoleUser, writeErr.Role)
+		assert.Equal(t, []RejectedField{
+			{Field: "parent_id", Access: FieldAccessSystemOnly},
+			{Field: "account_id", Access: FieldAccessImmutable},
+		}, writeErr.Fields)
+		assert.Equal(t, []string{"parent_id", "account_id"}, writeErr.RejectedFieldNames())
+		assert.Equal(t, "field not writable: role user cannot write parent_id (system_only), account_id (immutable)", err.Error())
+
+		assert.Equal(t, original, *b)
+	})
+
+	t.Run("system writes system-only fields but not immutable ones", func(t *testing.T) {
+		b := newBlock()
+		parentID := uuid.New()
+
+		_, err := b.UpdateFromJSONWithPolicy([]byte(`{"parent_id": "`+parentID.String()+`"}`), WriteRoleSystem, policy)
+		assert.NoError(t, err)
+		assert.Equal(t, parentID, *b.ParentID)
+
+		_, err = b.UpdateFromJSONWithPolicy([]byte(`{"created_at": "2020-01-01T00:00:00Z"}`), WriteRoleSystem, policy)
+		assert.ErrorIs(t, err, ErrFieldNotWritable)
+	})
+
+	t.Run("user clears enrichment results", func(t *testing.T) {
+		b := newBlock()
+		b.Meaning = "A note about bread"
+		b.Classification = Classification{"recipe": 0.9}
+
+		updatedFields, err := b.UpdateFromJSONWithPolicy([]byte(`{"meaning": null, "classification": null}`), WriteRoleUser, policy)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"meaning", "classification", "updated_at"}, updatedFields)
+		assert.Empty(t, b.Meaning)
+		assert.Empty(t, b.Classification)
+	})
+
+	t.Run("unknown fields are system-only", func(t *testing.T) {
+		assert.Equal(t, FieldAccessSystemOnly, policy.Access("dense_vector"))
+		assert.Error(t, policy.Check(WriteRoleUser, []string{"dense_vector"}))
+		assert.NoError(t, policy.Check(WriteRoleSystem, []string{"dense_vector"}))
+	})
+}
@@ -307,3 +307,15 @@ func GetYoutubeProperties() []string {
 		PropertyKeyChecked,
 	}
 }
+
+func init() {
+	mustRegisterType(TypeDefinition{
+		DataType:     TypeYouTube,
+		RenderFunc:   renderWith(RenderYoutubeProperties),
+		PropertyKeys: GetYoutubeProperties(),
+		Content:      BlockContentTypeStructural,
+		Schema: NewPropertySchema(GetYoutubeProperties()...).
+			Require(PropertyKeyURL).
+			WithEnum(PropertyKeyDefinition, "hd", "sd"),
+	})
+}